@@ -2,25 +2,94 @@ package esquery
 
 import (
 	"fmt"
-	"maps"
+	"slices"
+	"strings"
 )
 
 // AggsMap 聚合参数的Map
 type AggsMap struct {
-	Aggs Map    // 聚合的参数,多副本共享(引用类型特性)
+	Aggs Map    // 聚合的参数, With、Nested、Named返回新的副本
 	key  string // 当前聚合的名称，各对象独立(值类型特性)
+	err  error  // 组合时的错误, 如聚合名称重复
 }
 
-// With 组合聚合分析()
+// With 组合聚合分析(), 返回新的AggsMap, 不修改原聚合, 聚合名称重复时保留原聚合并记录错误, 通过Err或Build获取
 func (a AggsMap) With(m AggsMap) AggsMap {
-	maps.Copy(a.Aggs, m.Aggs)
-	return a
+	aggs, _ := deepCopy(a.Aggs).(Map)
+	if aggs == nil {
+		aggs = Map{}
+	}
+	err := a.err
+	if err == nil {
+		err = m.err
+	}
+	for name, body := range m.Aggs {
+		if _, ok := aggs[name]; ok {
+			if err == nil {
+				err = fmt.Errorf("duplicate aggregation name %s, use Named to rename", name)
+			}
+			continue
+		}
+		aggs[name] = deepCopy(body)
+	}
+	return AggsMap{Aggs: aggs, key: a.key, err: err}
+}
+
+// Named 指定当前聚合的名称, 替代默认的 field_type 名称, 如 TermsAgg("brand").Named("by_brand")
+// 名称已被其它聚合使用时不重命名并记录错误
+func (a AggsMap) Named(name string) AggsMap {
+	if name == a.key {
+		return a
+	}
+	aggs, _ := deepCopy(a.Aggs).(Map)
+	body, ok := aggs[a.key]
+	if !ok {
+		return AggsMap{Aggs: aggs, key: a.key, err: a.errOr(fmt.Errorf("rename aggregation %q not found", a.key))}
+	}
+	if _, ok := aggs[name]; ok {
+		return AggsMap{Aggs: aggs, key: a.key, err: a.errOr(fmt.Errorf("duplicate aggregation name %s", name))}
+	}
+	aggs[name] = body
+	delete(aggs, a.key)
+	return AggsMap{Aggs: aggs, key: name, err: a.err}
 }
 
-// Nested 嵌套的聚合分析,联合聚合
+// Name 当前聚合的名称, 用于按名称解析结果
+func (a AggsMap) Name() string {
+	return a.key
+}
+
+// Err 组合聚合时的错误, 如聚合名称重复
+func (a AggsMap) Err() error {
+	return a.err
+}
+
+// Build 返回聚合参数, 用于ESQuery的Aggs, 组合聚合时出错则返回错误
+func (a AggsMap) Build() (Map, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.Aggs, nil
+}
+
+// Nested 嵌套的聚合分析,联合聚合, 子聚合替换当前聚合已有的子聚合, 返回新的AggsMap
+// 追加子聚合或构造更深、更复杂的聚合树可使用AggNode的SubAggs
 func (a AggsMap) Nested(m AggsMap) AggsMap {
-	a.Aggs[a.key].(Map)["aggs"] = m.Aggs
-	return a
+	aggs, _ := deepCopy(a.Aggs).(Map)
+	body, ok := aggs[a.key].(Map)
+	if !ok {
+		return AggsMap{Aggs: aggs, key: a.key, err: a.errOr(fmt.Errorf("nested aggregation %q not found", a.key))}
+	}
+	body["aggs"] = deepCopy(m.Aggs)
+	return AggsMap{Aggs: aggs, key: a.key, err: a.errOr(m.err)}
+}
+
+// errOr 返回已有的错误, 没有时返回err
+func (a AggsMap) errOr(err error) error {
+	if a.err != nil {
+		return a.err
+	}
+	return err
 }
 
 // Aggregation 构造聚合查询（支持 Option 模式）
@@ -117,9 +186,51 @@ func GeohashGridAgg(field string, opts ...Option) AggsMap {
 	return Aggregation(field, "geohash_grid", opts...)
 }
 
-// FilterAgg 构造 Filter 聚合查询, 基于查询条件进行过滤并聚合
-func FilterAgg(field string, opts ...Option) AggsMap {
-	return Aggregation(field, "filter", opts...)
+// FilterAgg 构造 Filter 聚合查询, 对满足查询条件的文档进行聚合, 一般通过Nested添加子聚合
+// @param name 聚合名称
+// @param query 过滤条件, 如Term、Range、Bool
+func FilterAgg(name string, query Map) AggsMap {
+	return AggsMap{Aggs: Map{name: Map{"filter": query}}, key: name}
+}
+
+// FiltersAgg 构造 Filters 聚合查询, 每个过滤条件生成一个命名的桶
+// @param name 聚合名称
+// @param filters 桶名称到过滤条件的映射
+// @param opts option不定参数, 如WithOtherBucket、WithOtherBucketKey
+func FiltersAgg(name string, filters map[string]Map, opts ...Option) AggsMap {
+	paramMap := NewOptMap(opts...)
+	named := make(Map, len(filters))
+	for k, q := range filters {
+		named[k] = q
+	}
+	paramMap["filters"] = named
+	return AggsMap{Aggs: Map{name: Map{"filters": paramMap}}, key: name}
+}
+
+// AnonymousFiltersAgg 构造匿名的 Filters 聚合查询, 桶按过滤条件的顺序返回
+// @param name 聚合名称
+// @param filters 过滤条件列表
+// @param opts option不定参数, 如WithOtherBucket
+func AnonymousFiltersAgg(name string, filters []Map, opts ...Option) AggsMap {
+	paramMap := NewOptMap(opts...)
+	paramMap["filters"] = filters
+	return AggsMap{Aggs: Map{name: Map{"filters": paramMap}}, key: name}
+}
+
+// WithOtherBucket 是否返回不满足任何过滤条件的文档桶(filters)
+// @param value 默认false
+func WithOtherBucket(value bool) Option {
+	return func(m Map) {
+		m["other_bucket"] = value
+	}
+}
+
+// WithOtherBucketKey 其它文档桶的名称, 设置后自动开启other_bucket(filters)
+// @param value 桶名称, 默认_other_
+func WithOtherBucketKey(value string) Option {
+	return func(m Map) {
+		m["other_bucket_key"] = value
+	}
 }
 
 // NestedAgg 构造 Nested 聚合查询, 用于嵌套查询的聚合
@@ -142,11 +253,6 @@ func TermsSetAgg(field string, opts ...Option) AggsMap {
 	return Aggregation(field, "terms_set", opts...)
 }
 
-// BucketSortAgg 构造 Bucket Sort 聚合查询, 对聚合结果桶进行排序
-func BucketSortAgg(field string, opts ...Option) AggsMap {
-	return Aggregation(field, "bucket_sort", opts...)
-}
-
 // ScriptedMetricAgg 构造 Scripted Metric 聚合查询, 使用自定义脚本执行聚合操作
 func ScriptedMetricAgg(field string, opts ...Option) AggsMap {
 	return Aggregation(field, "scripted_metric", opts...)
@@ -156,3 +262,199 @@ func ScriptedMetricAgg(field string, opts ...Option) AggsMap {
 func CompositeAgg(field string, opts ...Option) AggsMap {
 	return Aggregation(field, "composite", opts...)
 }
+
+// AggRange 构造range、date_range、ip_range聚合的一个范围, 用于WithRanges, 包含from, 不包含to
+// @param key 桶名称, 为空时由ES生成
+// @param from 起始值, nil表示不限, date_range可为日期数学表达式, 如 now-1M/M
+// @param to 结束值, nil表示不限
+func AggRange(key string, from, to any) Map {
+	r := Map{}
+	if key != "" {
+		r["key"] = key
+	}
+	if from != nil {
+		r["from"] = from
+	}
+	if to != nil {
+		r["to"] = to
+	}
+	return r
+}
+
+// AggMaskRange 构造ip_range聚合的CIDR范围, 用于WithRanges
+// @param key 桶名称, 为空时使用mask
+// @param mask CIDR, 如 10.0.0.0/25
+func AggMaskRange(key, mask string) Map {
+	r := Map{"mask": mask}
+	if key != "" {
+		r["key"] = key
+	}
+	return r
+}
+
+// DateRangeAgg 构造 Date Range 聚合查询, 按日期范围分组, 范围可使用日期数学表达式
+// 日期数学表达式按时区取整, 默认时区同DateHistogramAgg
+// @param field 日期字段
+// @param opts option不定参数, 如WithRanges([]Map{AggRange("last_month", "now-1M/M", "now/M")})、WithFormat、WithTimeZone
+func DateRangeAgg(field string, opts ...Option) AggsMap {
+	defaultOpts := []Option{WithTimeZone(Shanghai)}
+	defaultOpts = append(defaultOpts, opts...)
+	return Aggregation(field, "date_range", defaultOpts...)
+}
+
+// IPRangeAgg 构造 IP Range 聚合查询, 按IP范围或CIDR分组
+// @param field ip字段
+// @param opts option不定参数, 如WithRanges([]Map{AggMaskRange("", "10.0.0.0/25")})
+func IPRangeAgg(field string, opts ...Option) AggsMap {
+	return Aggregation(field, "ip_range", opts...)
+}
+
+// MultiTermsAgg 构造 Multi Terms 聚合查询, 按多个字段值的组合分组, 名称为 字段1_字段2_multi_terms
+// @param fields 分组字段, 至少两个
+// @param opts option不定参数, 如WithSize、WithOrder、WithShardSize
+// 字段少于两个时记录错误, 通过Err或Build获取
+func MultiTermsAgg(fields []string, opts ...Option) AggsMap {
+	paramMap := NewOptMap(opts...)
+	terms := make([]Map, 0, len(fields))
+	for _, f := range fields {
+		terms = append(terms, Map{"field": f})
+	}
+	paramMap["terms"] = terms
+	name := strings.Join(fields, "_") + "_multi_terms"
+	a := AggsMap{Aggs: Map{name: Map{"multi_terms": paramMap}}, key: name}
+	if len(fields) < 2 {
+		a.err = fmt.Errorf("multi_terms aggregation requires at least 2 fields, got %d", len(fields))
+	}
+	return a
+}
+
+// RareTermsAgg 构造 Rare Terms 聚合查询, 查找出现次数少的词项
+// @param field 分组字段
+// @param opts option不定参数, 如WithMaxDocCount
+func RareTermsAgg(field string, opts ...Option) AggsMap {
+	return Aggregation(field, "rare_terms", opts...)
+}
+
+// AutoDateHistogramAgg 构造 Auto Date Histogram 聚合查询, 由ES选择间隔使桶数不超过目标值
+// @param field 日期字段
+// @param buckets 目标桶数
+// @param opts option不定参数, 如WithMinimumInterval、WithFormat、WithTimeZone
+func AutoDateHistogramAgg(field string, buckets int, opts ...Option) AggsMap {
+	defaultOpts := []Option{WithTimeZone(Shanghai)}
+	defaultOpts = append(defaultOpts, opts...)
+	defaultOpts = append(defaultOpts, withBuckets(buckets))
+	return Aggregation(field, "auto_date_histogram", defaultOpts...)
+}
+
+// VariableWidthHistogramAgg 构造 Variable Width Histogram 聚合查询, 按数据分布生成不等宽的区间
+// @param field 数值字段
+// @param buckets 目标桶数
+// @param opts option不定参数, 如WithShardSize、WithInitialBuffer
+func VariableWidthHistogramAgg(field string, buckets int, opts ...Option) AggsMap {
+	return Aggregation(field, "variable_width_histogram", slices.Concat(opts, []Option{withBuckets(buckets)})...)
+}
+
+// GlobalAgg 构造 Global 聚合查询, 对索引中的全部文档聚合, 不受查询条件限制, 只能作为顶层聚合
+// @param name 聚合名称
+func GlobalAgg(name string) AggsMap {
+	return AggsMap{Aggs: Map{name: Map{"global": Map{}}}, key: name}
+}
+
+// MissingAgg 构造 Missing 聚合查询, 统计字段缺失或为null的文档
+// @param field 字段
+func MissingAgg(field string, opts ...Option) AggsMap {
+	return Aggregation(field, "missing", opts...)
+}
+
+// SamplerAgg 构造 Sampler 聚合查询, 子聚合只处理每个分片得分最高的文档
+// @param name 聚合名称
+// @param shardSize 每个分片的采样数, ES默认100
+func SamplerAgg(name string, shardSize int) AggsMap {
+	return AggsMap{Aggs: Map{name: Map{"sampler": Map{"shard_size": shardSize}}}, key: name}
+}
+
+// DiversifiedSamplerAgg 构造 Diversified Sampler 聚合查询, 采样时限制同一字段值的文档数, 提高样本多样性
+// @param name 聚合名称
+// @param field 去重字段
+// @param shardSize 每个分片的采样数, ES默认100
+// @param opts option不定参数, 如WithMaxDocsPerValue
+func DiversifiedSamplerAgg(name, field string, shardSize int, opts ...Option) AggsMap {
+	paramMap := NewOptMap(opts...)
+	paramMap["field"] = field
+	paramMap["shard_size"] = shardSize
+	return AggsMap{Aggs: Map{name: Map{"diversified_sampler": paramMap}}, key: name}
+}
+
+// ReverseNestedAgg 构造 Reverse Nested 聚合查询, 在nested聚合内回到父文档聚合
+// @param name 聚合名称
+// @param opts option不定参数, WithPath指定回到的上级嵌套路径, 默认回到根文档
+func ReverseNestedAgg(name string, opts ...Option) AggsMap {
+	return AggsMap{Aggs: Map{name: Map{"reverse_nested": NewOptMap(opts...)}}, key: name}
+}
+
+// ChildrenAgg 构造 Children 聚合查询, 从父文档聚合到指定类型的子文档(join字段)
+// @param name 聚合名称
+// @param childType 子文档的关系名称
+func ChildrenAgg(name, childType string) AggsMap {
+	return AggsMap{Aggs: Map{name: Map{"children": Map{"type": childType}}}, key: name}
+}
+
+// ParentAgg 构造 Parent 聚合查询, 从子文档聚合到其父文档(join字段)
+// @param name 聚合名称
+// @param childType 子文档的关系名称
+func ParentAgg(name, childType string) AggsMap {
+	return AggsMap{Aggs: Map{name: Map{"parent": Map{"type": childType}}}, key: name}
+}
+
+// GeotileGridAgg 构造 Geotile Grid 聚合查询, 按地图瓦片分组, 桶的key为 zoom/x/y
+// @param field 地理位置字段
+// @param opts option不定参数, 如WithPrecision(0-29, 默认7)、WithSize
+func GeotileGridAgg(field string, opts ...Option) AggsMap {
+	return Aggregation(field, "geotile_grid", opts...)
+}
+
+// GeohexGridAgg 构造 Geohex Grid 聚合查询, 按H3六边形网格分组
+// @param field 地理位置字段
+// @param opts option不定参数, 如WithPrecision(0-15, 默认6)、WithSize
+func GeohexGridAgg(field string, opts ...Option) AggsMap {
+	return Aggregation(field, "geohex_grid", opts...)
+}
+
+// withBuckets 目标桶数(auto_date_histogram、variable_width_histogram)
+func withBuckets(value int) Option {
+	return func(m Map) {
+		m["buckets"] = value
+	}
+}
+
+// WithMaxDocCount 词项的最大文档数, 超过则不算稀有(rare_terms)
+// @param value ES默认1, 最大100
+func WithMaxDocCount(value int) Option {
+	return func(m Map) {
+		m["max_doc_count"] = value
+	}
+}
+
+// WithMinimumInterval 最小的时间间隔(auto_date_histogram)
+// @param value 如 minute、hour、day、month、year
+func WithMinimumInterval(value string) Option {
+	return func(m Map) {
+		m["minimum_interval"] = value
+	}
+}
+
+// WithInitialBuffer 分片在合并区间前缓存的文档数(variable_width_histogram)
+// @param value ES默认为buckets的50倍
+func WithInitialBuffer(value int) Option {
+	return func(m Map) {
+		m["initial_buffer"] = value
+	}
+}
+
+// WithMaxDocsPerValue 每个字段值最多采样的文档数(diversified_sampler)
+// @param value ES默认1
+func WithMaxDocsPerValue(value int) Option {
+	return func(m Map) {
+		m["max_docs_per_value"] = value
+	}
+}
@@ -0,0 +1,291 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// metricAggs 指标聚合, 不能包含子聚合
+var metricAggs = []string{
+	"avg", "sum", "min", "max", "value_count", "cardinality", "stats", "extended_stats", "percentiles",
+	"percentile_ranks", "top_hits", "top_metrics", "scripted_metric", "weighted_avg", "median_absolute_deviation",
+	"geo_bounds", "geo_centroid", "string_stats", "boxplot", "rate", "t_test",
+}
+
+// pipelineAggs 管道聚合, 不能包含子聚合
+var pipelineAggs = []string{
+	"derivative", "cumulative_sum", "cumulative_cardinality", "moving_fn", "serial_diff", "bucket_script",
+	"bucket_selector", "bucket_sort", "avg_bucket", "max_bucket", "min_bucket", "sum_bucket", "stats_bucket",
+	"extended_stats_bucket", "percentiles_bucket",
+}
+
+// singleBucketAggs 只产生一个桶的聚合
+var singleBucketAggs = []string{
+	"filter", "nested", "reverse_nested", "global", "missing", "sampler", "diversified_sampler", "children", "parent",
+}
+
+// AggNode 不可变的聚合树节点, 组合方法返回新节点, 参数深拷贝, 不与其它节点共享
+type AggNode struct {
+	name    string    // 聚合名称
+	aggType string    // 聚合类型
+	params  Map       // 聚合参数
+	subs    []AggNode // 子聚合
+	meta    Map       // 元数据, 原样返回
+	err     error     // 构造时的错误
+}
+
+// NewAggNode 构造聚合树节点
+// @param name 聚合名称
+// @param aggType 聚合类型, 如terms、date_histogram、sum
+// @param params 聚合参数
+func NewAggNode(name, aggType string, params Map) AggNode {
+	p, _ := deepCopy(params).(Map)
+	if p == nil {
+		p = Map{}
+	}
+	return AggNode{name: name, aggType: aggType, params: p}
+}
+
+// Node 将当前聚合(含已嵌套的子聚合)转为聚合树节点
+func (a AggsMap) Node() AggNode {
+	body, _ := aggBody(a.Aggs[a.key])
+	n := nodeFromMap(a.key, body)
+	if n.err == nil {
+		n.err = a.err
+	}
+	return n
+}
+
+// Nodes 将组合的所有聚合转为聚合树节点, 按名称排序
+// 聚合体不是Map时(如json.RawMessage、结构体)按json转换, 无法转换的聚合被跳过, 不参与校验
+func (a AggsMap) Nodes() []AggNode {
+	names := make([]string, 0, len(a.Aggs))
+	for name := range a.Aggs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nodes := make([]AggNode, 0, len(names))
+	for _, name := range names {
+		body, ok := aggBody(a.Aggs[name])
+		if !ok {
+			continue
+		}
+		nodes = append(nodes, nodeFromMap(name, body))
+	}
+	return nodes
+}
+
+// aggBody 将聚合体转为Map
+func aggBody(v any) (Map, bool) {
+	if m, ok := v.(Map); ok {
+		return m, true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil || m == nil {
+		return nil, false
+	}
+	return m, true
+}
+
+// nodeFromMap 从 {aggType: params, "aggs": {...}} 解析聚合树节点
+func nodeFromMap(name string, body Map) AggNode {
+	n := AggNode{name: name, params: Map{}}
+	for k, v := range body {
+		switch k {
+		case "aggs", "aggregations":
+			subs, _ := aggBody(v)
+			n.subs = AggsMap{Aggs: subs}.Nodes()
+		case "meta":
+			n.meta, _ = deepCopy(v).(Map)
+		default:
+			if n.aggType != "" {
+				n.err = fmt.Errorf("aggregation %s has multiple types %s and %s", name, n.aggType, k)
+			}
+			n.aggType = k
+			n.params, _ = deepCopy(v).(Map)
+		}
+	}
+	if n.aggType == "" && n.err == nil {
+		n.err = fmt.Errorf("aggregation %s has no type", name)
+	}
+	return n
+}
+
+// Name 聚合名称
+func (n AggNode) Name() string { return n.name }
+
+// Type 聚合类型
+func (n AggNode) Type() string { return n.aggType }
+
+// Named 返回指定名称的新节点
+func (n AggNode) Named(name string) AggNode {
+	n.name = name
+	return n
+}
+
+// SubAggs 返回追加了子聚合的新节点, 子聚合之间为兄弟关系
+func (n AggNode) SubAggs(children ...AggNode) AggNode {
+	subs := make([]AggNode, 0, len(n.subs)+len(children))
+	subs = append(subs, n.subs...)
+	subs = append(subs, children...)
+	n.subs = subs
+	return n
+}
+
+// Children 子聚合
+func (n AggNode) Children() []AggNode {
+	return slices.Clone(n.subs)
+}
+
+// Map 生成聚合的DSL, 即 {aggType: params, "aggs": {...}}
+func (n AggNode) Map() Map {
+	body := Map{n.aggType: deepCopy(n.params)}
+	if n.meta != nil {
+		body["meta"] = deepCopy(n.meta)
+	}
+	if len(n.subs) > 0 {
+		subs := Map{}
+		for _, child := range n.subs {
+			subs[child.name] = child.Map()
+		}
+		body["aggs"] = subs
+	}
+	return body
+}
+
+// Validate 校验聚合树, 如名称重复、指标聚合或管道聚合包含子聚合、global不在顶层、buckets_path引用了不存在的聚合
+func (n AggNode) Validate() error {
+	return n.validate(nil, []AggNode{n})
+}
+
+// validateNodes 校验同级的多个节点
+func validateNodes(nodes, ancestors []AggNode) error {
+	if err := validateSiblings(nodes); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err := n.validate(ancestors, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate 按祖先节点和同级节点校验
+func (n AggNode) validate(ancestors, siblings []AggNode) error {
+	if n.err != nil {
+		return n.err
+	}
+	if n.name == "" {
+		return fmt.Errorf("%s aggregation requires name", n.aggType)
+	}
+	if strings.ContainsAny(n.name, "[]>") {
+		return fmt.Errorf("aggregation name %s contains [, ] or >", n.name)
+	}
+	if len(n.subs) > 0 && (slices.Contains(metricAggs, n.aggType) || slices.Contains(pipelineAggs, n.aggType)) {
+		return fmt.Errorf("%s aggregation %s cannot have sub-aggregations", n.aggType, n.name)
+	}
+
+	switch n.aggType {
+	case "global":
+		if len(ancestors) > 0 {
+			return fmt.Errorf("global aggregation %s must be top level", n.name)
+		}
+	case "reverse_nested":
+		if !slices.ContainsFunc(ancestors, func(a AggNode) bool { return a.aggType == "nested" }) {
+			return fmt.Errorf("reverse_nested aggregation %s must be inside a nested aggregation", n.name)
+		}
+	case "composite":
+		for _, a := range ancestors {
+			if !slices.Contains(singleBucketAggs, a.aggType) {
+				return fmt.Errorf("composite aggregation %s cannot be under %s aggregation %s", n.name, a.aggType, a.name)
+			}
+		}
+	}
+
+	if slices.Contains(pipelineAggs, n.aggType) {
+		if err := n.validatePipeline(ancestors, siblings); err != nil {
+			return err
+		}
+	}
+
+	if err := validateSiblings(n.subs); err != nil {
+		return fmt.Errorf("aggregation %s: %w", n.name, err)
+	}
+	return validateNodes(n.subs, append(slices.Clone(ancestors), n))
+}
+
+// validateSiblings 校验同级聚合的名称不重复
+func validateSiblings(nodes []AggNode) error {
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		if seen[n.name] {
+			return fmt.Errorf("duplicate aggregation name %s", n.name)
+		}
+		seen[n.name] = true
+	}
+	return nil
+}
+
+// BuildAggs 校验并生成顶层聚合的DSL, 用于ESQuery的Aggs, 多个节点为兄弟关系
+func BuildAggs(nodes ...AggNode) (Map, error) {
+	if err := validateNodes(nodes, nil); err != nil {
+		return nil, err
+	}
+	aggs := Map{}
+	for _, n := range nodes {
+		aggs[n.name] = n.Map()
+	}
+	return aggs, nil
+}
+
+// deepCopy 深拷贝Map、切片、map等组合值(如WithPercents的[]float64、buckets_path的map[string]string), 其它值原样返回
+func deepCopy(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), deepCopyValue(iter.Value(), rv.Type().Elem()))
+		}
+		return out.Interface()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out.Index(i).Set(deepCopyValue(rv.Index(i), rv.Type().Elem()))
+		}
+		return out.Interface()
+	}
+	return v
+}
+
+// deepCopyValue 深拷贝map或切片的元素, t为元素类型
+func deepCopyValue(v reflect.Value, t reflect.Type) reflect.Value {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		v = v.Elem()
+	}
+	c := deepCopy(v.Interface())
+	if c == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(c)
+}
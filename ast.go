@@ -0,0 +1,732 @@
+package esquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// errUnknownParam 已支持的查询类型中出现未类型化的参数
+var errUnknownParam = errors.New("unknown query parameter")
+
+// Querier 类型化的查询节点, 可与Map构造的查询互相嵌套
+// 因Query已用于查询重入信息, 故命名为Querier
+type Querier interface {
+	json.Marshaler
+	// Source 生成查询DSL, 参数不合法时返回错误
+	Source() (any, error)
+}
+
+// marshalQuery 序列化查询节点
+func marshalQuery(q Querier) ([]byte, error) {
+	src, err := q.Source()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(src)
+}
+
+// RawQuery 包装Map构造的查询, 用于迁移期间与类型化查询混用
+type RawQuery Map
+
+// Source 生成查询DSL
+func (q RawQuery) Source() (any, error) { return Map(q), nil }
+
+// MarshalJSON json序列化
+func (q RawQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// MatchAllQuery 匹配全部文档
+type MatchAllQuery struct {
+	Boost float64 // 权重
+}
+
+// Source 生成查询DSL
+func (q MatchAllQuery) Source() (any, error) {
+	params := Map{}
+	setNonZero(params, "boost", q.Boost)
+	return Map{"match_all": params}, nil
+}
+
+// MarshalJSON json序列化
+func (q MatchAllQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// TermQuery 精确匹配
+type TermQuery struct {
+	Field           string  // 查询字段
+	Value           any     // 查询值, 不进行分词
+	Boost           float64 // 权重
+	CaseInsensitive bool    // 是否忽略大小写
+}
+
+// Source 生成查询DSL
+func (q TermQuery) Source() (any, error) {
+	if q.Field == "" {
+		return nil, fmt.Errorf("term query requires field")
+	}
+	params := Map{"value": q.Value}
+	setNonZero(params, "boost", q.Boost)
+	setNonZero(params, "case_insensitive", q.CaseInsensitive)
+	return Map{"term": Map{q.Field: params}}, nil
+}
+
+// MarshalJSON json序列化
+func (q TermQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// TermsQuery 多值精确匹配
+type TermsQuery struct {
+	Field  string  // 查询字段
+	Values []any   // 查询值列表
+	Boost  float64 // 权重
+}
+
+// NewTermsQuery 以任意类型的切片构造TermsQuery
+func NewTermsQuery[V any](field string, values ...V) TermsQuery {
+	vs := make([]any, 0, len(values))
+	for _, v := range values {
+		vs = append(vs, v)
+	}
+	return TermsQuery{Field: field, Values: vs}
+}
+
+// Source 生成查询DSL
+func (q TermsQuery) Source() (any, error) {
+	if q.Field == "" {
+		return nil, fmt.Errorf("terms query requires field")
+	}
+	values := q.Values
+	if values == nil {
+		values = []any{}
+	}
+	params := Map{q.Field: values}
+	setNonZero(params, "boost", q.Boost)
+	return Map{"terms": params}, nil
+}
+
+// MarshalJSON json序列化
+func (q TermsQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// MatchQuery 全文检索
+type MatchQuery struct {
+	Field              string  // 查询字段
+	Query              any     // 查询值, 进行分词
+	Operator           string  // 词之间的逻辑关系AND/OR
+	Fuzziness          any     // 模糊度AUTO/个数
+	Analyzer           string  // 分词器
+	MinimumShouldMatch any     // 最少匹配的词个数或百分比
+	ZeroTermsQuery     string  // 分词后为空时的行为none/all
+	Boost              float64 // 权重
+}
+
+// Source 生成查询DSL
+func (q MatchQuery) Source() (any, error) {
+	if q.Field == "" {
+		return nil, fmt.Errorf("match query requires field")
+	}
+	params := Map{"query": q.Query}
+	setNonZero(params, "operator", q.Operator)
+	setNonZero(params, "fuzziness", q.Fuzziness)
+	setNonZero(params, "analyzer", q.Analyzer)
+	setNonZero(params, "minimum_should_match", q.MinimumShouldMatch)
+	setNonZero(params, "zero_terms_query", q.ZeroTermsQuery)
+	setNonZero(params, "boost", q.Boost)
+	return Map{"match": Map{q.Field: params}}, nil
+}
+
+// MarshalJSON json序列化
+func (q MatchQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// MultiMatchQuery 多字段全文检索
+type MultiMatchQuery struct {
+	Query              any      // 查询值
+	Fields             []string // 查询字段列表
+	Type               string   // 匹配类型, 如BestFields
+	Operator           string   // 词之间的逻辑关系AND/OR
+	Fuzziness          any      // 模糊度AUTO/个数
+	Analyzer           string   // 分词器
+	MinimumShouldMatch any      // 最少匹配的词个数或百分比
+	TieBreaker         float64  // 非最佳字段得分的系数
+	Boost              float64  // 权重
+}
+
+// Source 生成查询DSL
+func (q MultiMatchQuery) Source() (any, error) {
+	if len(q.Fields) == 0 {
+		return nil, fmt.Errorf("multi_match query requires fields")
+	}
+	params := Map{"query": q.Query, "fields": q.Fields}
+	setNonZero(params, "type", q.Type)
+	setNonZero(params, "operator", q.Operator)
+	setNonZero(params, "fuzziness", q.Fuzziness)
+	setNonZero(params, "analyzer", q.Analyzer)
+	setNonZero(params, "minimum_should_match", q.MinimumShouldMatch)
+	setNonZero(params, "tie_breaker", q.TieBreaker)
+	setNonZero(params, "boost", q.Boost)
+	return Map{"multi_match": params}, nil
+}
+
+// MarshalJSON json序列化
+func (q MultiMatchQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// RangeQuery 范围查询
+type RangeQuery struct {
+	Field    string  // 查询字段
+	Gte      any     // (>=)大于等于
+	Gt       any     // (>)大于
+	Lt       any     // (<)小于
+	Lte      any     // (<=)小于等于
+	Format   string  // 日期格式
+	TimeZone string  // 时区
+	Relation string  // range类型字段的匹配关系
+	Boost    float64 // 权重
+}
+
+// Source 生成查询DSL
+func (q RangeQuery) Source() (any, error) {
+	if q.Field == "" {
+		return nil, fmt.Errorf("range query requires field")
+	}
+	if q.Gte != nil && q.Gt != nil {
+		return nil, fmt.Errorf("range query on %s sets both gte and gt", q.Field)
+	}
+	if q.Lte != nil && q.Lt != nil {
+		return nil, fmt.Errorf("range query on %s sets both lte and lt", q.Field)
+	}
+	params := Map{}
+	setNonZero(params, "gte", q.Gte)
+	setNonZero(params, "gt", q.Gt)
+	setNonZero(params, "lt", q.Lt)
+	setNonZero(params, "lte", q.Lte)
+	setNonZero(params, "format", q.Format)
+	setNonZero(params, "time_zone", q.TimeZone)
+	setNonZero(params, "relation", q.Relation)
+	setNonZero(params, "boost", q.Boost)
+	return Map{"range": Map{q.Field: params}}, nil
+}
+
+// MarshalJSON json序列化
+func (q RangeQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// ExistsQuery 字段存在查询
+type ExistsQuery struct {
+	Field string // 判断的字段
+}
+
+// Source 生成查询DSL
+func (q ExistsQuery) Source() (any, error) {
+	if q.Field == "" {
+		return nil, fmt.Errorf("exists query requires field")
+	}
+	return Map{"exists": Map{"field": q.Field}}, nil
+}
+
+// MarshalJSON json序列化
+func (q ExistsQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// WildcardQuery 通配符查询
+type WildcardQuery struct {
+	Field           string  // 查询字段
+	Value           string  // 通配符表达式
+	Boost           float64 // 权重
+	CaseInsensitive bool    // 是否忽略大小写
+}
+
+// Source 生成查询DSL
+func (q WildcardQuery) Source() (any, error) {
+	if q.Field == "" {
+		return nil, fmt.Errorf("wildcard query requires field")
+	}
+	params := Map{"value": q.Value}
+	setNonZero(params, "boost", q.Boost)
+	setNonZero(params, "case_insensitive", q.CaseInsensitive)
+	return Map{"wildcard": Map{q.Field: params}}, nil
+}
+
+// MarshalJSON json序列化
+func (q WildcardQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// NestedQuery 嵌套字段查询
+type NestedQuery struct {
+	Path           string  // 嵌套字段路径
+	Query          Querier // 嵌套文档上的查询
+	ScoreMode      string  // 评分模式
+	IgnoreUnmapped bool    // 是否忽略未映射的路径
+}
+
+// Source 生成查询DSL
+func (q NestedQuery) Source() (any, error) {
+	if q.Path == "" || q.Query == nil {
+		return nil, fmt.Errorf("nested query requires path and query")
+	}
+	inner, err := q.Query.Source()
+	if err != nil {
+		return nil, err
+	}
+	params := Map{"path": q.Path, "query": inner}
+	setNonZero(params, "score_mode", q.ScoreMode)
+	setNonZero(params, "ignore_unmapped", q.IgnoreUnmapped)
+	return Map{"nested": params}, nil
+}
+
+// MarshalJSON json序列化
+func (q NestedQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// BoolQuery 布尔组合查询
+type BoolQuery struct {
+	Must               []Querier // 必须满足, 参与打分
+	Should             []Querier // 应该满足, 参与打分
+	Filter             []Querier // 必须满足, 不参与打分
+	MustNot            []Querier // 必须不满足
+	MinimumShouldMatch any       // should最少满足的个数或百分比
+	Boost              float64   // 权重
+}
+
+// Source 生成查询DSL
+func (q BoolQuery) Source() (any, error) {
+	params := Map{}
+	clauses := []struct {
+		key string
+		qs  []Querier
+	}{{"must", q.Must}, {"should", q.Should}, {"filter", q.Filter}, {"must_not", q.MustNot}}
+	for _, c := range clauses {
+		if len(c.qs) == 0 {
+			continue
+		}
+		srcs := make([]any, 0, len(c.qs))
+		for _, sub := range c.qs {
+			src, err := sub.Source()
+			if err != nil {
+				return nil, fmt.Errorf("bool %s: %w", c.key, err)
+			}
+			srcs = append(srcs, src)
+		}
+		params[c.key] = srcs
+	}
+	setNonZero(params, "minimum_should_match", q.MinimumShouldMatch)
+	setNonZero(params, "boost", q.Boost)
+	return Map{"bool": params}, nil
+}
+
+// MarshalJSON json序列化
+func (q BoolQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// setNonZero 非零值时设置参数
+func setNonZero(m Map, key string, value any) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+	case float64:
+		if v == 0 {
+			return
+		}
+	case bool:
+		if !v {
+			return
+		}
+	}
+	m[key] = value
+}
+
+// ToMap 将类型化查询转为Map, 便于传给现有的Map构造函数
+func ToMap(q Querier) (Map, error) {
+	src, err := q.Source()
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := src.(Map); ok {
+		return m, nil
+	}
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		return nil, err
+	}
+	var m Map
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// FromMap 将Map构造的查询解析为类型化查询
+func FromMap(m Map) (Querier, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return ParseQuery(data)
+}
+
+// ParseQuery 将查询DSL的json解析为类型化查询, 未支持的查询类型保留为RawQuery
+// 已支持的查询类型中出现未类型化的参数(如_name、lenient)时, 该查询整体保留为RawQuery
+func ParseQuery(data []byte) (Querier, error) {
+	q, err := parseTyped(data)
+	if !errors.Is(err, errUnknownParam) {
+		return q, err
+	}
+
+	var raw Map
+	if err := decodeJSON(data, &raw, false); err != nil {
+		return nil, err
+	}
+	return RawQuery(raw), nil
+}
+
+// parseTyped 解析为类型化查询, 出现未类型化的参数时返回errUnknownParam
+func parseTyped(data []byte) (Querier, error) {
+	var outer map[string]json.RawMessage
+	if err := decodeJSON(data, &outer, false); err != nil {
+		return nil, fmt.Errorf("parse query failed: %w", err)
+	}
+	if len(outer) != 1 {
+		return nil, fmt.Errorf("query must have exactly one type, got %d", len(outer))
+	}
+
+	for qtype, body := range outer {
+		switch qtype {
+		case "match_all":
+			return parseMatchAll(body)
+		case "term":
+			return parseTerm(body)
+		case "terms":
+			return parseTerms(body)
+		case "match":
+			return parseMatch(body)
+		case "multi_match":
+			return parseMultiMatch(body)
+		case "range":
+			return parseRange(body)
+		case "exists":
+			return parseExists(body)
+		case "wildcard":
+			return parseWildcard(body)
+		case "nested":
+			return parseNested(body)
+		case "bool":
+			return parseBool(body)
+		}
+	}
+
+	var raw Map
+	if err := decodeJSON(data, &raw, false); err != nil {
+		return nil, err
+	}
+	return RawQuery(raw), nil
+}
+
+// decodeJSON 解析json, 保留数字原样, strict时不允许未知字段
+func decodeJSON(data []byte, v any, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(v)
+	if strict && err != nil && strings.HasPrefix(err.Error(), "json: unknown field") {
+		return fmt.Errorf("%w: %w", errUnknownParam, err)
+	}
+	return err
+}
+
+// splitField 将 {field: body, 参数...} 形式拆分为字段名、字段体及其它参数
+func splitField(qtype string, body json.RawMessage, optKeys ...string) (string, json.RawMessage, map[string]json.RawMessage, error) {
+	var m map[string]json.RawMessage
+	if err := decodeJSON(body, &m, false); err != nil {
+		return "", nil, nil, fmt.Errorf("parse %s query failed: %w", qtype, err)
+	}
+
+	var field string
+	var fieldBody json.RawMessage
+	opts := map[string]json.RawMessage{}
+	for k, v := range m {
+		if slices.Contains(optKeys, k) {
+			opts[k] = v
+			continue
+		}
+		if field != "" {
+			return "", nil, nil, fmt.Errorf("%s query has multiple fields: %s, %s", qtype, field, k)
+		}
+		field, fieldBody = k, v
+	}
+	if field == "" {
+		return "", nil, nil, fmt.Errorf("%s query requires field", qtype)
+	}
+	return field, fieldBody, opts, nil
+}
+
+// decodeOpts 将拆分出的参数解析到结构体
+func decodeOpts(opts map[string]json.RawMessage, v any) error {
+	if len(opts) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, v, true)
+}
+
+// isObject 判断json是否为对象
+func isObject(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+// jsonFloat 转换json数字
+func jsonFloat(n json.Number) float64 {
+	f, _ := n.Float64()
+	return f
+}
+
+func parseMatchAll(body json.RawMessage) (Querier, error) {
+	var p struct {
+		Boost json.Number `json:"boost"`
+	}
+	if err := decodeJSON(body, &p, true); err != nil {
+		return nil, fmt.Errorf("parse match_all query failed: %w", err)
+	}
+	return MatchAllQuery{Boost: jsonFloat(p.Boost)}, nil
+}
+
+// termParams term、wildcard的字段参数
+type termParams struct {
+	Value           any         `json:"value"`
+	Boost           json.Number `json:"boost"`
+	CaseInsensitive bool        `json:"case_insensitive"`
+}
+
+func parseTerm(body json.RawMessage) (Querier, error) {
+	field, fb, opts, err := splitField("term", body, "boost", "case_insensitive")
+	if err != nil {
+		return nil, err
+	}
+
+	var p termParams
+	if isObject(fb) {
+		err = decodeJSON(fb, &p, true)
+	} else {
+		err = decodeJSON(fb, &p.Value, false)
+	}
+	if err == nil {
+		err = decodeOpts(opts, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse term query failed: %w", err)
+	}
+	return TermQuery{Field: field, Value: p.Value, Boost: jsonFloat(p.Boost), CaseInsensitive: p.CaseInsensitive}, nil
+}
+
+func parseTerms(body json.RawMessage) (Querier, error) {
+	field, fb, opts, err := splitField("terms", body, "boost")
+	if err != nil {
+		return nil, err
+	}
+
+	// terms lookup形式无类型化结构, 保留原样
+	if isObject(fb) {
+		var raw Map
+		err = decodeJSON(body, &raw, false)
+		return RawQuery{"terms": raw}, err
+	}
+
+	var values []any
+	var p struct {
+		Boost json.Number `json:"boost"`
+	}
+	err = decodeJSON(fb, &values, false)
+	if err == nil {
+		err = decodeOpts(opts, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse terms query failed: %w", err)
+	}
+	return TermsQuery{Field: field, Values: values, Boost: jsonFloat(p.Boost)}, nil
+}
+
+// matchParams match的字段参数
+type matchParams struct {
+	Query              any         `json:"query"`
+	Operator           string      `json:"operator"`
+	Fuzziness          any         `json:"fuzziness"`
+	Analyzer           string      `json:"analyzer"`
+	MinimumShouldMatch any         `json:"minimum_should_match"`
+	ZeroTermsQuery     string      `json:"zero_terms_query"`
+	Boost              json.Number `json:"boost"`
+}
+
+func parseMatch(body json.RawMessage) (Querier, error) {
+	field, fb, opts, err := splitField("match", body,
+		"operator", "fuzziness", "analyzer", "minimum_should_match", "zero_terms_query", "boost")
+	if err != nil {
+		return nil, err
+	}
+
+	var p matchParams
+	if isObject(fb) {
+		err = decodeJSON(fb, &p, true)
+	} else {
+		err = decodeJSON(fb, &p.Query, false)
+	}
+	if err == nil {
+		err = decodeOpts(opts, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse match query failed: %w", err)
+	}
+	return MatchQuery{
+		Field: field, Query: p.Query, Operator: p.Operator, Fuzziness: p.Fuzziness, Analyzer: p.Analyzer,
+		MinimumShouldMatch: p.MinimumShouldMatch, ZeroTermsQuery: p.ZeroTermsQuery, Boost: jsonFloat(p.Boost),
+	}, nil
+}
+
+func parseMultiMatch(body json.RawMessage) (Querier, error) {
+	var p struct {
+		Query              any         `json:"query"`
+		Fields             []string    `json:"fields"`
+		Type               string      `json:"type"`
+		Operator           string      `json:"operator"`
+		Fuzziness          any         `json:"fuzziness"`
+		Analyzer           string      `json:"analyzer"`
+		MinimumShouldMatch any         `json:"minimum_should_match"`
+		TieBreaker         json.Number `json:"tie_breaker"`
+		Boost              json.Number `json:"boost"`
+	}
+	if err := decodeJSON(body, &p, true); err != nil {
+		return nil, fmt.Errorf("parse multi_match query failed: %w", err)
+	}
+	return MultiMatchQuery{
+		Query: p.Query, Fields: p.Fields, Type: p.Type, Operator: p.Operator, Fuzziness: p.Fuzziness,
+		Analyzer: p.Analyzer, MinimumShouldMatch: p.MinimumShouldMatch,
+		TieBreaker: jsonFloat(p.TieBreaker), Boost: jsonFloat(p.Boost),
+	}, nil
+}
+
+func parseRange(body json.RawMessage) (Querier, error) {
+	field, fb, _, err := splitField("range", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var p struct {
+		Gte      any         `json:"gte"`
+		Gt       any         `json:"gt"`
+		Lt       any         `json:"lt"`
+		Lte      any         `json:"lte"`
+		Format   string      `json:"format"`
+		TimeZone string      `json:"time_zone"`
+		Relation string      `json:"relation"`
+		Boost    json.Number `json:"boost"`
+	}
+	if err := decodeJSON(fb, &p, true); err != nil {
+		return nil, fmt.Errorf("parse range query failed: %w", err)
+	}
+	return RangeQuery{
+		Field: field, Gte: p.Gte, Gt: p.Gt, Lt: p.Lt, Lte: p.Lte,
+		Format: p.Format, TimeZone: p.TimeZone, Relation: p.Relation, Boost: jsonFloat(p.Boost),
+	}, nil
+}
+
+func parseExists(body json.RawMessage) (Querier, error) {
+	var p struct {
+		Field string `json:"field"`
+	}
+	if err := decodeJSON(body, &p, true); err != nil {
+		return nil, fmt.Errorf("parse exists query failed: %w", err)
+	}
+	return ExistsQuery{Field: p.Field}, nil
+}
+
+func parseWildcard(body json.RawMessage) (Querier, error) {
+	field, fb, _, err := splitField("wildcard", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var p termParams
+	if isObject(fb) {
+		err = decodeJSON(fb, &p, true)
+	} else {
+		err = decodeJSON(fb, &p.Value, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse wildcard query failed: %w", err)
+	}
+	value, _ := p.Value.(string)
+	return WildcardQuery{Field: field, Value: value, Boost: jsonFloat(p.Boost), CaseInsensitive: p.CaseInsensitive}, nil
+}
+
+func parseNested(body json.RawMessage) (Querier, error) {
+	var p struct {
+		Path           string          `json:"path"`
+		Query          json.RawMessage `json:"query"`
+		ScoreMode      string          `json:"score_mode"`
+		IgnoreUnmapped bool            `json:"ignore_unmapped"`
+	}
+	if err := decodeJSON(body, &p, true); err != nil {
+		return nil, fmt.Errorf("parse nested query failed: %w", err)
+	}
+	inner, err := ParseQuery(p.Query)
+	if err != nil {
+		return nil, fmt.Errorf("nested %s: %w", p.Path, err)
+	}
+	return NestedQuery{Path: p.Path, Query: inner, ScoreMode: p.ScoreMode, IgnoreUnmapped: p.IgnoreUnmapped}, nil
+}
+
+func parseBool(body json.RawMessage) (Querier, error) {
+	var p struct {
+		Must               json.RawMessage `json:"must"`
+		Should             json.RawMessage `json:"should"`
+		Filter             json.RawMessage `json:"filter"`
+		MustNot            json.RawMessage `json:"must_not"`
+		MinimumShouldMatch any             `json:"minimum_should_match"`
+		Boost              json.Number     `json:"boost"`
+	}
+	if err := decodeJSON(body, &p, true); err != nil {
+		return nil, fmt.Errorf("parse bool query failed: %w", err)
+	}
+
+	q := BoolQuery{MinimumShouldMatch: p.MinimumShouldMatch, Boost: jsonFloat(p.Boost)}
+	var err error
+	if q.Must, err = parseClauses("must", p.Must); err != nil {
+		return nil, err
+	}
+	if q.Should, err = parseClauses("should", p.Should); err != nil {
+		return nil, err
+	}
+	if q.Filter, err = parseClauses("filter", p.Filter); err != nil {
+		return nil, err
+	}
+	if q.MustNot, err = parseClauses("must_not", p.MustNot); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// parseClauses 解析bool子句, 兼容单个对象和数组两种写法
+func parseClauses(key string, data json.RawMessage) ([]Querier, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var items []json.RawMessage
+	if isObject(data) {
+		items = []json.RawMessage{data}
+	} else if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse bool %s failed: %w", key, err)
+	}
+
+	qs := make([]Querier, 0, len(items))
+	for _, item := range items {
+		q, err := ParseQuery(item)
+		if err != nil {
+			return nil, fmt.Errorf("bool %s: %w", key, err)
+		}
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
@@ -0,0 +1,233 @@
+package esquery
+
+import "maps"
+
+// FunctionScore 构造 FunctionScore 查询, 用评分函数调整查询的相关度得分, 如按热度、新鲜度加权
+// @param query 查询语句
+// @param functions 评分函数列表, 由ScoreFunc及各函数构造
+// @param opts option不定参数, 如WithScoreMode、WithBoostMode、WithMaxBoost、WithMinScore
+func FunctionScore(query Map, functions []Map, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["query"] = query
+	paramMap["functions"] = functions
+	return Map{"function_score": paramMap}
+}
+
+// ScoreFunc 为评分函数附加过滤条件和权重, 仅对满足filter的文档生效
+// @param fn 评分函数, 如FieldValueFactor、GaussDecay
+// @param opts option不定参数, WithFilter、WithWeight
+func ScoreFunc(fn Map, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	maps.Copy(paramMap, fn)
+	return paramMap
+}
+
+// FieldValueFactor 构造按字段值计算得分的函数, 如按点赞数加权
+// @param field 数值字段
+// @param opts option不定参数, 如WithFactor、WithModifier、WithMissing
+func FieldValueFactor(field string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["field"] = field
+	return Map{"field_value_factor": paramMap}
+}
+
+// 衰减函数类型
+var (
+	DecayGauss  = "gauss"  // 高斯衰减, 离原点较近时衰减缓慢
+	DecayExp    = "exp"    // 指数衰减, 离原点越近衰减越快
+	DecayLinear = "linear" // 线性衰减, 超出scale后得分为0
+)
+
+// Decay 构造衰减函数, 距原点越远得分越低
+// @param kind 衰减类型DecayGauss/DecayExp/DecayLinear
+// @param field 数值、日期或地理坐标字段
+// @param origin 原点, 数值、日期(为nil时默认now)或地理坐标
+// @param scale 距原点offset+scale时得分衰减为decay, 如 10、"7d"、"2km"
+// @param opts option不定参数, 如WithOffset、WithDecay
+func Decay(kind, field string, origin, scale any, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	if origin != nil {
+		paramMap["origin"] = origin
+	}
+	paramMap["scale"] = scale
+	return Map{
+		kind: Map{
+			field: paramMap,
+		},
+	}
+}
+
+// GaussDecay 构造高斯衰减函数
+func GaussDecay(field string, origin, scale any, opts ...Option) Map {
+	return Decay(DecayGauss, field, origin, scale, opts...)
+}
+
+// ExpDecay 构造指数衰减函数
+func ExpDecay(field string, origin, scale any, opts ...Option) Map {
+	return Decay(DecayExp, field, origin, scale, opts...)
+}
+
+// LinearDecay 构造线性衰减函数
+func LinearDecay(field string, origin, scale any, opts ...Option) Map {
+	return Decay(DecayLinear, field, origin, scale, opts...)
+}
+
+// RandomScore 构造随机得分函数, 相同seed和field时得分可复现
+// @param seed 随机种子, 为nil时每次查询结果不同
+// @param field 计算随机值的字段, 指定seed时需指定, 一般为_seq_no
+func RandomScore(seed any, field string) Map {
+	paramMap := Map{}
+	if seed != nil {
+		paramMap["seed"] = seed
+	}
+	if field != "" {
+		paramMap["field"] = field
+	}
+	return Map{"random_score": paramMap}
+}
+
+// WeightScore 构造固定权重函数, 一般配合WithFilter对部分文档加权
+// @param weight 权重
+func WeightScore(weight float64) Map {
+	return Map{"weight": weight}
+}
+
+// ScriptScoreFunc 构造脚本得分函数
+// @param source 计算得分的脚本
+// @param opts option不定参数, 为script指定参数
+func ScriptScoreFunc(source string, opts ...Option) Map {
+	return Map{"script_score": Map{"script": newScript(source, opts...)}}
+}
+
+// DisMax 构造 DisMax 查询, 取子查询的最高得分, 其余子查询按tie_breaker比例加分
+// @param queries 子查询列表
+// @param opts option不定参数, 如WithTieBreaker、WithBoost
+func DisMax(queries []Map, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["queries"] = queries
+	return Map{"dis_max": paramMap}
+}
+
+// Boosting 构造 Boosting 查询, 匹配negative的文档降低得分而不排除
+// @param positive 必须满足的查询
+// @param negative 需要降权的查询
+// @param negativeBoost 降权系数, 0到1之间
+// @param opts option不定参数
+func Boosting(positive, negative Map, negativeBoost float64, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["positive"] = positive
+	paramMap["negative"] = negative
+	paramMap["negative_boost"] = negativeBoost
+	return Map{"boosting": paramMap}
+}
+
+// ConstantScore 构造 ConstantScore 查询, 以过滤方式执行查询并给所有命中文档相同得分
+// @param filter 过滤条件
+// @param opts option不定参数, 如WithBoost
+func ConstantScore(filter Map, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["filter"] = filter
+	return Map{"constant_score": paramMap}
+}
+
+// 函数得分的合并方式(score_mode)及与查询得分的合并方式(boost_mode), 另见AVG、MIN、MAX、SUM
+var (
+	ScoreModeMultiply = "multiply" // 函数得分相乘(默认)
+	ScoreModeFirst    = "first"    // 取第一个匹配filter的函数得分
+	BoostModeMultiply = "multiply" // 与查询得分相乘(默认)
+	BoostModeReplace  = "replace"  // 仅使用函数得分, 忽略查询得分
+)
+
+// WithBoostMode 函数得分与查询得分的合并方式(function_score)
+// @param value BoostModeMultiply/BoostModeReplace/SUM/AVG/MAX/MIN
+func WithBoostMode(value string) Option {
+	return func(m Map) {
+		m["boost_mode"] = value
+	}
+}
+
+// WithMaxBoost 函数得分的上限(function_score)
+// @param value 上限值
+func WithMaxBoost(value float64) Option {
+	return func(m Map) {
+		m["max_boost"] = value
+	}
+}
+
+// WithMinScore 低于该得分的文档被排除
+// @param value 最低得分
+func WithMinScore(value float64) Option {
+	return func(m Map) {
+		m["min_score"] = value
+	}
+}
+
+// WithWeight 评分函数的权重
+// @param value 权重
+func WithWeight(value float64) Option {
+	return func(m Map) {
+		m["weight"] = value
+	}
+}
+
+// field_value_factor的modifier枚举值
+var (
+	ModifierNone       = "none"
+	ModifierLog        = "log"        // log10(x), x小于1时为负数, 一般用log1p
+	ModifierLog1p      = "log1p"      // log10(x+1)
+	ModifierLog2p      = "log2p"      // log10(x+2)
+	ModifierLn         = "ln"         // ln(x)
+	ModifierLn1p       = "ln1p"       // ln(x+1)
+	ModifierLn2p       = "ln2p"       // ln(x+2)
+	ModifierSquare     = "square"     // x^2
+	ModifierSqrt       = "sqrt"       // √x
+	ModifierReciprocal = "reciprocal" // 1/x
+)
+
+// WithFactor 字段值的乘数(field_value_factor)
+// @param value 乘数, 默认1
+func WithFactor(value float64) Option {
+	return func(m Map) {
+		m["factor"] = value
+	}
+}
+
+// WithModifier 字段值的变换函数(field_value_factor)
+// @param value 如ModifierLog1p
+func WithModifier(value string) Option {
+	return func(m Map) {
+		m["modifier"] = value
+	}
+}
+
+// WithMissing 字段缺失时使用的默认值
+// @param value 默认值
+func WithMissing(value any) Option {
+	return func(m Map) {
+		m["missing"] = value
+	}
+}
+
+// WithOffset 距原点offset范围内不衰减(decay)
+// @param value 如 0、"1d"、"500m"
+func WithOffset(value any) Option {
+	return func(m Map) {
+		m["offset"] = value
+	}
+}
+
+// WithDecay 距原点offset+scale处的得分(decay)
+// @param value 0到1之间, 默认0.5
+func WithDecay(value float64) Option {
+	return func(m Map) {
+		m["decay"] = value
+	}
+}
+
+// WithTieBreaker 非最高得分子查询的得分系数(dis_max/multi_match)
+// @param value 0到1之间, 默认0
+func WithTieBreaker(value float64) Option {
+	return func(m Map) {
+		m["tie_breaker"] = value
+	}
+}
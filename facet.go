@@ -0,0 +1,252 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// facetValuesAgg 分面内部实际统计取值的子聚合名称
+const facetValuesAgg = "values"
+
+// FacetDef 分面定义
+type FacetDef struct {
+	Name     string   // 分面名称, 同时作为聚合名称
+	Field    string   // 分面字段
+	Agg      AggsMap  // 统计分面取值的聚合, TermsAgg或RangeAgg
+	Filter   Map      // 选中值对应的过滤条件, 未选中时为nil
+	Selected []string // 选中的值, 经facetKey规范化, terms为词项, range为范围的key
+}
+
+// TermsFacet 构造按词项分组的分面
+// @param name 分面名称
+// @param field 分面字段, 需为keyword等可聚合类型
+// @param selected 用户选中的值, 多个值之间为OR关系
+// @param opts TermsAgg的option不定参数
+func TermsFacet(name, field string, selected []any, opts ...Option) FacetDef {
+	f := FacetDef{Name: name, Field: field, Agg: TermsAgg(field, opts...)}
+	if len(selected) > 0 {
+		f.Filter = Terms(field, selected)
+		for _, v := range selected {
+			f.Selected = append(f.Selected, facetKey(v))
+		}
+	}
+	return f
+}
+
+// RangeFacet 构造按范围分组的分面
+// @param name 分面名称
+// @param field 分面字段, 需为数值类型
+// @param ranges 范围列表, 每个范围需指定key, 如 {"key": "cheap", "to": 100}
+// @param selected 用户选中的范围key, 多个范围之间为OR关系
+// @param opts RangeAgg的option不定参数
+func RangeFacet(name, field string, ranges []Map, selected []string, opts ...Option) FacetDef {
+	f := FacetDef{Name: name, Field: field, Agg: RangeAgg(field, slices.Concat(opts, []Option{WithRanges(ranges)})...),
+		Selected: selected}
+
+	var should []Map
+	for _, r := range ranges {
+		if !slices.Contains(selected, facetKey(r["key"])) {
+			continue
+		}
+		// range聚合的区间为[from, to)
+		should = append(should, Range(field, r["from"], nil, r["to"], nil))
+	}
+	if len(should) > 0 {
+		f.Filter = Bool(WithShould(should), WithMinimumShouldMatch(1))
+	}
+	return f
+}
+
+// FacetQuery 构造多选分面查询
+// 命中结果由post_filter按全部选中值过滤, 每个分面的聚合只按其它分面的选中值过滤,
+// 从而保证分面内的其它取值仍可见且计数正确
+// @param query 基础查询条件
+// @param facets 分面定义列表, 名称不能重复
+func FacetQuery(query Map, facets ...FacetDef) (*ESQuery, error) {
+	if err := checkFacetNames(facets); err != nil {
+		return nil, err
+	}
+	esQuery := &ESQuery{Query: query, Aggs: Map{}}
+
+	var filters []Map
+	for _, f := range facets {
+		if f.Filter != nil {
+			filters = append(filters, f.Filter)
+		}
+	}
+	if len(filters) > 0 {
+		esQuery.PostFilter = Bool(WithFilter(filters))
+	}
+
+	for _, f := range facets {
+		others := []Map{}
+		for _, o := range facets {
+			if o.Name != f.Name && o.Filter != nil {
+				others = append(others, o.Filter)
+			}
+		}
+		esQuery.Aggs[f.Name] = Map{
+			"filter": Bool(WithFilter(others)),
+			"aggs":   Map{facetValuesAgg: f.Agg.Aggs[f.Agg.key]},
+		}
+	}
+	return esQuery, nil
+}
+
+// checkFacetNames 校验分面名称非空且不重复, 重复时聚合会相互覆盖
+func checkFacetNames(facets []FacetDef) error {
+	seen := map[string]bool{}
+	for _, f := range facets {
+		if f.Name == "" {
+			return fmt.Errorf("facet on %s requires name", f.Field)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate facet name %s", f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+// FacetValue 分面的一个取值
+type FacetValue struct {
+	Key      string   `json:"key"`            // 取值, range分面为范围的key
+	From     *float64 `json:"from,omitempty"` // 范围起始(range)
+	To       *float64 `json:"to,omitempty"`   // 范围结束(range)
+	Count    int      `json:"count"`          // 文档数
+	Selected bool     `json:"selected"`       // 是否被选中
+}
+
+// Facet 分面统计结果
+type Facet struct {
+	Name   string       `json:"name"`   // 分面名称
+	Field  string       `json:"field"`  // 分面字段
+	Values []FacetValue `json:"values"` // 取值列表
+}
+
+// facetBucket 分面聚合的桶
+type facetBucket struct {
+	Key         json.RawMessage `json:"key"`
+	KeyAsString string          `json:"key_as_string"`
+	From        *float64        `json:"from"`
+	To          *float64        `json:"to"`
+	DocCount    int             `json:"doc_count"`
+}
+
+// ParseFacets 从聚合结果中解析分面
+// @param aggs 查询结果的聚合部分
+// @param facets 构造查询时使用的分面定义
+func ParseFacets(aggs map[string]json.RawMessage, facets ...FacetDef) ([]Facet, error) {
+	if err := checkFacetNames(facets); err != nil {
+		return nil, err
+	}
+	result := make([]Facet, 0, len(facets))
+	for _, f := range facets {
+		raw, ok := aggs[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("facet %s not found in aggregations", f.Name)
+		}
+
+		var agg struct {
+			Values struct {
+				Buckets []facetBucket `json:"buckets"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(raw, &agg); err != nil {
+			return nil, fmt.Errorf("decode facet %s failed: %w", f.Name, err)
+		}
+
+		facet := Facet{Name: f.Name, Field: f.Field, Values: []FacetValue{}}
+		seen := map[string]bool{}
+		for _, b := range agg.Values.Buckets {
+			selected := false
+			for _, k := range b.keys() {
+				seen[k] = true
+				selected = selected || slices.Contains(f.Selected, k)
+			}
+			facet.Values = append(facet.Values, FacetValue{
+				Key:      b.keyString(),
+				From:     b.From,
+				To:       b.To,
+				Count:    b.DocCount,
+				Selected: selected,
+			})
+		}
+
+		// 选中但已无命中的值仍需返回, 以便界面保持选中状态
+		for _, s := range f.Selected {
+			if !seen[s] {
+				facet.Values = append(facet.Values, FacetValue{Key: s, Selected: true})
+			}
+		}
+		result = append(result, facet)
+	}
+	return result, nil
+}
+
+// keyString 桶的key转为字符串, 用于展示, 优先使用key_as_string
+func (b facetBucket) keyString() string {
+	if b.KeyAsString != "" {
+		return b.KeyAsString
+	}
+	return rawFacetKey(b.Key)
+}
+
+// keys 桶可匹配选中值的规范化key, 如布尔字段的 1 和 true, 日期字段的毫秒时间戳和格式化的时间
+func (b facetBucket) keys() []string {
+	keys := []string{rawFacetKey(b.Key)}
+	if b.KeyAsString != "" {
+		keys = append(keys, b.KeyAsString)
+	}
+	return keys
+}
+
+// facetKey 将选中值规范化为与桶key相同的字符串形式, 时间转为毫秒时间戳, 数值去掉多余的小数位
+func facetKey(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time:
+		return strconv.FormatInt(val.UnixMilli(), 10)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return rawFacetKey(data)
+}
+
+// rawFacetKey 将json格式的key规范化为字符串, 字符串去掉引号, 数值如 1.0 转为 1
+func rawFacetKey(data []byte) string {
+	if s, err := strconv.Unquote(string(data)); err == nil {
+		return s
+	}
+	if f, err := strconv.ParseFloat(string(data), 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return string(data)
+}
+
+// QueryFacets 执行多选分面查询, 返回过滤后的详情及各分面统计
+func QueryFacets[T any](es *elasticsearch.Client, index string, query Map, facets ...FacetDef,
+) ([]*T, int, []Facet, error) {
+	esQuery, err := FacetQuery(query, facets...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	l, t, aggs, _, err := QueryWithMeta[T](es, index, esQuery)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	fs, err := ParseFacets(aggs, facets...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return l, t, fs, nil
+}
@@ -0,0 +1,71 @@
+package esquery
+
+// fieldQuery 构造 {qtype: {field: {"query": value, 参数...}}} 形式的查询
+func fieldQuery(qtype, field string, value any, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["query"] = value
+	return Map{
+		qtype: Map{
+			field: paramMap,
+		},
+	}
+}
+
+// MatchPhrase 构造 MatchPhrase 查询, 词项需按顺序相邻出现
+// @param field 查询字段
+// @param value 查询短语
+// @param opts option不定参数, 如WithSlop、WithAnalyzer
+func MatchPhrase(field string, value string, opts ...Option) Map {
+	return fieldQuery("match_phrase", field, value, opts...)
+}
+
+// MatchPhrasePrefix 构造 MatchPhrasePrefix 查询, 短语匹配且最后一个词按前缀匹配, 适用于输入即搜索
+// @param field 查询字段
+// @param value 查询短语
+// @param opts option不定参数, 如WithSlop、WithMaxExpansions
+func MatchPhrasePrefix(field string, value string, opts ...Option) Map {
+	return fieldQuery("match_phrase_prefix", field, value, opts...)
+}
+
+// MatchBoolPrefix 构造 MatchBoolPrefix 查询, 分词后各词组成bool查询, 最后一个词按前缀匹配, 不要求词序
+// @param field 查询字段
+// @param value 查询值
+// @param opts option不定参数, 如WithOperator、WithMinimumShouldMatch、WithFuzziness
+func MatchBoolPrefix(field string, value string, opts ...Option) Map {
+	return fieldQuery("match_bool_prefix", field, value, opts...)
+}
+
+// CombinedFields 构造 CombinedFields 查询, 将多个字段视为一个组合字段进行BM25打分
+// @param query 查询值
+// @param fields 查询字段列表, 可用^指定权重如 "title^2"
+// @param opts option不定参数, 如WithOperator、WithMinimumShouldMatch、WithAutoGenerateSynonymsPhraseQuery
+func CombinedFields(query string, fields []string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["query"] = query
+	paramMap["fields"] = fields
+	return Map{"combined_fields": paramMap}
+}
+
+// QueryString 构造 QueryString 查询, 使用Lucene语法(AND/OR/NOT、通配符、字段限定等)
+// 语法错误会导致查询失败, 直接使用用户输入时应使用EscapeQueryString转义、WithInputPolicy或改用SafeQueryString
+// @param query Lucene语法的查询表达式
+// @param opts option不定参数, 如WithDefaultField、WithFields、WithDefaultOperator、WithInputPolicy
+func QueryString(query string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	var policy InputPolicy
+	var ok bool
+	paramMap["query"], policy, ok = policyValue(paramMap, queryStringRule, query)
+	if ok && policy == PolicyAllow {
+		paramMap["allow_leading_wildcard"] = false
+	}
+	return Map{"query_string": paramMap}
+}
+
+// SimpleQueryString 构造 SimpleQueryString 查询, 使用简化语法(+ | - " * 等), 忽略语法错误
+// @param query 查询表达式
+// @param opts option不定参数, 如WithFields、WithDefaultOperator、WithFlags、WithInputPolicy
+func SimpleQueryString(query string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["query"], _, _ = policyValue(paramMap, simpleQueryStringRule, query)
+	return Map{"simple_query_string": paramMap}
+}
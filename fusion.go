@@ -0,0 +1,250 @@
+package esquery
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// 融合方式
+const (
+	FusionRRF    = "rrf"    // 倒数排名融合(默认), 只依赖排名, 不受各路分数量纲影响
+	FusionLinear = "linear" // 归一化分数后加权求和
+)
+
+// 分数归一化方式, 线性融合时使用
+const (
+	NormMinMax = "minmax" // (s-min)/(max-min), 默认
+	NormZScore = "zscore" // (s-mean)/std
+)
+
+// DefaultRankConstant RRF的默认排名常数k
+const DefaultRankConstant = 60
+
+// FusionSource 参与融合的一路查询
+type FusionSource struct {
+	Name   string  // 查询名称, 用于区分各路的排名和分数, 为空时默认为q0、q1...
+	Query  any     // 查询语句, 一般为*ESQuery
+	Weight float64 // 权重, 为0时默认为1
+}
+
+// FusionHit 融合后的命中文档
+type FusionHit[T any] struct {
+	ID     string             `json:"id"`     // 文档ID
+	Score  float64            `json:"score"`  // 融合后的分数
+	Source *T                 `json:"source"` // 文档内容
+	Ranks  map[string]int     `json:"ranks"`  // 各路查询中的排名, 从1开始
+	Scores map[string]float64 `json:"scores"` // 各路查询中的原始分数
+}
+
+// WithFusionMethod 融合方式
+// @param value rrf(默认)/linear
+func WithFusionMethod(value string) Option {
+	return func(m Map) {
+		m["method"] = value
+	}
+}
+
+// WithRankConstant RRF的排名常数k, 越大则低排名文档的影响越大
+// @param value 常数k, 默认60
+func WithRankConstant(value int) Option {
+	return func(m Map) {
+		m["rank_constant"] = value
+	}
+}
+
+// WithNormalizer 线性融合时各路分数的归一化方式
+// @param value minmax(默认)/zscore
+func WithNormalizer(value string) Option {
+	return func(m Map) {
+		m["normalizer"] = value
+	}
+}
+
+// fusionConfig 融合参数
+type fusionConfig struct {
+	method       string
+	rankConstant int
+	normalizer   string
+	size         int
+}
+
+// newFusionConfig 从Option中解析融合参数
+func newFusionConfig(opts ...Option) fusionConfig {
+	cfg := fusionConfig{method: FusionRRF, rankConstant: DefaultRankConstant, normalizer: NormMinMax}
+	m := NewOptMap(opts...)
+	if v, ok := m["method"].(string); ok && v != "" {
+		cfg.method = v
+	}
+	if v, ok := m["rank_constant"].(int); ok && v > 0 {
+		cfg.rankConstant = v
+	}
+	if v, ok := m["normalizer"].(string); ok && v != "" {
+		cfg.normalizer = v
+	}
+	if v, ok := m["size"].(int); ok {
+		cfg.size = v
+	}
+	return cfg
+}
+
+// QueryFusion 对同一索引并发执行多路查询(如Match与Knn), 按_id融合排序结果
+// 适用于不支持RRF retriever的集群版本或许可
+// @param sources 参与融合的查询列表
+// @param opts WithFusionMethod、WithRankConstant、WithNormalizer、WithSize
+func QueryFusion[T any](es *elasticsearch.Client, index string, sources []FusionSource, opts ...Option,
+) ([]*FusionHit[T], error) {
+	cfg := newFusionConfig(opts...)
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+
+	lists := make([][]*Hit[T], len(sources))
+	errs := make([]error, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src FusionSource) {
+			defer wg.Done()
+			lists[i], _, errs[i] = QueryHits[T](es, index, src.Query)
+		}(i, src)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("fusion query %s failed: %w", sourceName(sources, i), err)
+		}
+	}
+	return fuseHits(sources, lists, cfg), nil
+}
+
+// FuseHits 融合已查询到的多路结果, lists与sources一一对应且各自按排名有序
+// @param opts WithFusionMethod、WithRankConstant、WithNormalizer、WithSize
+func FuseHits[T any](sources []FusionSource, lists [][]*Hit[T], opts ...Option) ([]*FusionHit[T], error) {
+	if len(sources) != len(lists) {
+		return nil, fmt.Errorf("fusion got %d sources but %d hit lists", len(sources), len(lists))
+	}
+	cfg := newFusionConfig(opts...)
+	if err := cfg.check(); err != nil {
+		return nil, err
+	}
+	return fuseHits(sources, lists, cfg), nil
+}
+
+// check 校验融合参数
+func (c fusionConfig) check() error {
+	switch c.method {
+	case FusionRRF:
+	case FusionLinear:
+		if c.normalizer != NormMinMax && c.normalizer != NormZScore {
+			return fmt.Errorf("unsupported fusion normalizer: %s", c.normalizer)
+		}
+	default:
+		return fmt.Errorf("unsupported fusion method: %s", c.method)
+	}
+	return nil
+}
+
+// fuseHits 按_id合并各路结果并计算融合分数
+func fuseHits[T any](sources []FusionSource, lists [][]*Hit[T], cfg fusionConfig) []*FusionHit[T] {
+	merged := map[string]*FusionHit[T]{}
+	var order []string // 首次出现的顺序, 保证同分时结果稳定
+	for i, hits := range lists {
+		name := sourceName(sources, i)
+		weight := sources[i].Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		var norm []float64
+		if cfg.method == FusionLinear {
+			norm = normalizeScores(hits, cfg.normalizer)
+		}
+
+		for rank, hit := range hits {
+			fh, ok := merged[hit.ID]
+			if !ok {
+				fh = &FusionHit[T]{ID: hit.ID, Ranks: map[string]int{}, Scores: map[string]float64{}}
+				merged[hit.ID] = fh
+				order = append(order, hit.ID)
+			}
+			if fh.Source == nil {
+				fh.Source = hit.Source
+			}
+			fh.Ranks[name] = rank + 1
+			fh.Scores[name] = hit.Score
+
+			if cfg.method == FusionLinear {
+				fh.Score += weight * norm[rank]
+			} else {
+				fh.Score += weight / float64(cfg.rankConstant+rank+1)
+			}
+		}
+	}
+
+	results := make([]*FusionHit[T], 0, len(order))
+	for _, id := range order {
+		results = append(results, merged[id])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if cfg.size > 0 && len(results) > cfg.size {
+		results = results[:cfg.size]
+	}
+	return results
+}
+
+// normalizeScores 归一化一路结果的分数
+func normalizeScores[T any](hits []*Hit[T], normalizer string) []float64 {
+	norm := make([]float64, len(hits))
+	if len(hits) == 0 {
+		return norm
+	}
+
+	switch normalizer {
+	case NormZScore:
+		var sum float64
+		for _, h := range hits {
+			sum += h.Score
+		}
+		mean := sum / float64(len(hits))
+		var variance float64
+		for _, h := range hits {
+			variance += (h.Score - mean) * (h.Score - mean)
+		}
+		std := math.Sqrt(variance / float64(len(hits)))
+		for i, h := range hits {
+			if std > 0 {
+				norm[i] = (h.Score - mean) / std
+			}
+		}
+	default:
+		lo, hi := hits[0].Score, hits[0].Score
+		for _, h := range hits {
+			lo = math.Min(lo, h.Score)
+			hi = math.Max(hi, h.Score)
+		}
+		for i, h := range hits {
+			if hi > lo {
+				norm[i] = (h.Score - lo) / (hi - lo)
+			} else {
+				norm[i] = 1 // 分数全部相同时视为同等最优
+			}
+		}
+	}
+	return norm
+}
+
+// sourceName 获取第i路查询的名称
+func sourceName(sources []FusionSource, i int) string {
+	if sources[i].Name != "" {
+		return sources[i].Name
+	}
+	return fmt.Sprintf("q%d", i)
+}
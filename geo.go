@@ -0,0 +1,265 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Geometry 地理图形, 可序列化为GeoJSON或WKT, json序列化统一为GeoJSON格式
+type Geometry interface {
+	GeoJSON() Map // GeoJSON格式
+	WKT() string  // WKT格式
+}
+
+// GeoPoint 地理坐标点, json序列化为GeoJSON, 反序列化兼容 {"lat", "lon"} 和GeoJSON格式
+type GeoPoint struct {
+	Lat float64 `json:"lat"` // 纬度
+	Lon float64 `json:"lon"` // 经度
+}
+
+// GeoJSON GeoJSON格式, 坐标顺序为[经度, 纬度]
+func (p GeoPoint) GeoJSON() Map {
+	return Map{"type": "Point", "coordinates": p.coordinates()}
+}
+
+// WKT WKT格式, 坐标顺序为"经度 纬度"
+func (p GeoPoint) WKT() string {
+	return "POINT (" + p.wkt() + ")"
+}
+
+// MarshalJSON 坐标点序列化为GeoJSON
+func (p GeoPoint) MarshalJSON() ([]byte, error) { return json.Marshal(p.GeoJSON()) }
+
+// UnmarshalJSON 反序列化 {"lat", "lon"} 或GeoJSON格式的坐标点
+func (p *GeoPoint) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Lat         *float64  `json:"lat"`
+		Lon         *float64  `json:"lon"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch {
+	case v.Lat != nil && v.Lon != nil:
+		p.Lat, p.Lon = *v.Lat, *v.Lon
+	case len(v.Coordinates) == 2:
+		p.Lon, p.Lat = v.Coordinates[0], v.Coordinates[1]
+	default:
+		return fmt.Errorf("invalid geo point %s", data)
+	}
+	return nil
+}
+
+// latLon 查询参数中的坐标格式, 如geo_distance、geo_bounding_box
+func (p GeoPoint) latLon() Map {
+	return Map{"lat": p.Lat, "lon": p.Lon}
+}
+
+// coordinates GeoJSON坐标
+func (p GeoPoint) coordinates() []float64 {
+	return []float64{p.Lon, p.Lat}
+}
+
+// wkt WKT坐标
+func (p GeoPoint) wkt() string {
+	return formatFloat(p.Lon) + " " + formatFloat(p.Lat)
+}
+
+// BBox 矩形范围
+type BBox struct {
+	TopLeft     GeoPoint `json:"top_left"`     // 左上角
+	BottomRight GeoPoint `json:"bottom_right"` // 右下角
+}
+
+// GeoJSON GeoJSON格式(ES扩展的envelope类型)
+func (b BBox) GeoJSON() Map {
+	return Map{"type": "envelope", "coordinates": [][]float64{b.TopLeft.coordinates(), b.BottomRight.coordinates()}}
+}
+
+// MarshalJSON 矩形范围序列化为GeoJSON
+func (b BBox) MarshalJSON() ([]byte, error) { return json.Marshal(b.GeoJSON()) }
+
+// WKT WKT格式(ES扩展的BBOX类型), 顺序为minLon, maxLon, maxLat, minLat
+func (b BBox) WKT() string {
+	return fmt.Sprintf("BBOX (%s, %s, %s, %s)", formatFloat(b.TopLeft.Lon), formatFloat(b.BottomRight.Lon),
+		formatFloat(b.TopLeft.Lat), formatFloat(b.BottomRight.Lat))
+}
+
+// Polygon 多边形, 第一个环为外边界, 其余为内部的洞, 环未闭合时自动闭合
+type Polygon [][]GeoPoint
+
+// GeoJSON GeoJSON格式
+func (p Polygon) GeoJSON() Map {
+	return Map{"type": "Polygon", "coordinates": p.coordinates()}
+}
+
+// WKT WKT格式
+func (p Polygon) WKT() string {
+	return "POLYGON " + p.wkt()
+}
+
+// MarshalJSON 多边形序列化为GeoJSON
+func (p Polygon) MarshalJSON() ([]byte, error) { return json.Marshal(p.GeoJSON()) }
+
+// coordinates GeoJSON坐标
+func (p Polygon) coordinates() [][][]float64 {
+	rings := make([][][]float64, 0, len(p))
+	for _, ring := range p {
+		coords := make([][]float64, 0, len(ring)+1)
+		for _, pt := range closeRing(ring) {
+			coords = append(coords, pt.coordinates())
+		}
+		rings = append(rings, coords)
+	}
+	return rings
+}
+
+// wkt WKT坐标
+func (p Polygon) wkt() string {
+	rings := make([]string, 0, len(p))
+	for _, ring := range p {
+		pts := make([]string, 0, len(ring)+1)
+		for _, pt := range closeRing(ring) {
+			pts = append(pts, pt.wkt())
+		}
+		rings = append(rings, "("+strings.Join(pts, ", ")+")")
+	}
+	return "(" + strings.Join(rings, ", ") + ")"
+}
+
+// MultiPolygon 多个多边形
+type MultiPolygon []Polygon
+
+// GeoJSON GeoJSON格式
+func (mp MultiPolygon) GeoJSON() Map {
+	coords := make([][][][]float64, 0, len(mp))
+	for _, p := range mp {
+		coords = append(coords, p.coordinates())
+	}
+	return Map{"type": "MultiPolygon", "coordinates": coords}
+}
+
+// WKT WKT格式
+func (mp MultiPolygon) WKT() string {
+	polys := make([]string, 0, len(mp))
+	for _, p := range mp {
+		polys = append(polys, p.wkt())
+	}
+	return "MULTIPOLYGON (" + strings.Join(polys, ", ") + ")"
+}
+
+// MarshalJSON 多个多边形序列化为GeoJSON
+func (mp MultiPolygon) MarshalJSON() ([]byte, error) { return json.Marshal(mp.GeoJSON()) }
+
+// closeRing 闭合环, 首尾坐标相同
+func closeRing(ring []GeoPoint) []GeoPoint {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		return append(ring[:len(ring):len(ring)], ring[0])
+	}
+	return ring
+}
+
+// formatFloat 格式化坐标, 不带多余的0
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// 地理图形的匹配关系
+var (
+	Intersects = "intersects" // 相交(默认)
+	Within     = "within"     // 字段图形在查询图形内
+	Disjoint   = "disjoint"   // 不相交
+	Contains   = "contains"   // 字段图形包含查询图形
+)
+
+// GeoDistanceAt 以类型化坐标构造 GeoDistance 查询
+// @param field 查询字段
+// @param origin 中心点
+// @param distance 距离阈值, 带单位km、mi、m、yd、ft
+// @param opts option不定参数, 如WithDistanceType、WithValidationMethod
+func GeoDistanceAt(field string, origin GeoPoint, distance string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["distance"] = distance
+	paramMap[field] = origin.latLon()
+	return Map{"geo_distance": paramMap}
+}
+
+// GeoBoundingBox 构造 GeoBoundingBox 查询, 匹配矩形范围内的坐标
+// @param field geo_point或geo_shape字段
+// @param box 矩形范围
+// @param opts option不定参数, 如WithValidationMethod、WithIgnoreUnmapped
+func GeoBoundingBox(field string, box BBox, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap[field] = Map{"top_left": box.TopLeft.latLon(), "bottom_right": box.BottomRight.latLon()}
+	return Map{"geo_bounding_box": paramMap}
+}
+
+// GeoBoundingBoxWKT 以WKT格式构造 GeoBoundingBox 查询
+// @param field geo_point或geo_shape字段
+// @param box 矩形范围
+// @param opts option不定参数
+func GeoBoundingBoxWKT(field string, box BBox, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap[field] = Map{"wkt": box.WKT()}
+	return Map{"geo_bounding_box": paramMap}
+}
+
+// GeoPolygon 构造 GeoPolygon 查询, 匹配多边形内的坐标
+// ES 7.12起已弃用, 新版本请使用GeoShape
+// @param field geo_point字段
+// @param points 多边形顶点
+// @param opts option不定参数, 如WithValidationMethod、WithIgnoreUnmapped
+func GeoPolygon(field string, points []GeoPoint, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	pts := make([]Map, 0, len(points))
+	for _, pt := range points {
+		pts = append(pts, pt.latLon())
+	}
+	paramMap[field] = Map{"points": pts}
+	return Map{"geo_polygon": paramMap}
+}
+
+// GeoShape 构造 GeoShape 查询, 按图形关系匹配, 图形以GeoJSON格式传递
+// @param field geo_shape或geo_point字段
+// @param shape 查询图形, 如GeoPoint、BBox、Polygon、MultiPolygon
+// @param relation 匹配关系Intersects/Within/Disjoint/Contains
+// @param opts option不定参数, 如WithIgnoreUnmapped
+func GeoShape(field string, shape Geometry, relation string, opts ...Option) Map {
+	return geoShape(field, "shape", shape.GeoJSON(), relation, opts...)
+}
+
+// GeoShapeWKT 构造 GeoShape 查询, 图形以WKT格式传递
+func GeoShapeWKT(field string, shape Geometry, relation string, opts ...Option) Map {
+	return geoShape(field, "shape", shape.WKT(), relation, opts...)
+}
+
+// IndexedShape 引用已索引文档中的图形
+type IndexedShape struct {
+	Index   string `json:"index"`             // 图形所在的索引
+	ID      string `json:"id"`                // 图形所在的文档ID
+	Path    string `json:"path,omitempty"`    // 图形所在的字段, 默认shape
+	Routing string `json:"routing,omitempty"` // 路由值
+}
+
+// GeoShapeIndexed 构造 GeoShape 查询, 查询图形引用已索引的文档
+// @param field geo_shape或geo_point字段
+// @param shape 引用的图形
+// @param relation 匹配关系Intersects/Within/Disjoint/Contains
+// @param opts option不定参数, 如WithIgnoreUnmapped
+func GeoShapeIndexed(field string, shape IndexedShape, relation string, opts ...Option) Map {
+	return geoShape(field, "indexed_shape", shape, relation, opts...)
+}
+
+// geoShape 构造geo_shape查询
+func geoShape(field, shapeKey string, shape any, relation string, opts ...Option) Map {
+	params := Map{shapeKey: shape}
+	if relation != "" {
+		params["relation"] = relation
+	}
+	paramMap := NewOptMap(opts...)
+	paramMap[field] = params
+	return Map{"geo_shape": paramMap}
+}
@@ -0,0 +1,160 @@
+package esquery
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// IntervalRule intervals查询的匹配规则, 过滤方法返回新的规则, 不修改原规则
+type IntervalRule struct {
+	kind   string // 规则类型match/prefix/wildcard/fuzzy/all_of/any_of
+	params Map    // 规则参数
+}
+
+// newIntervalRule 构造规则
+func newIntervalRule(kind string, params Map) IntervalRule {
+	return IntervalRule{kind: kind, params: params}
+}
+
+// Map 规则的DSL
+func (r IntervalRule) Map() Map {
+	return Map{r.kind: r.params}
+}
+
+// MarshalJSON json序列化
+func (r IntervalRule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Map())
+}
+
+// Intervals 构造 Intervals 查询, 按词项的顺序和距离进行邻近匹配
+// @param field 查询字段
+// @param rule 匹配规则
+func Intervals(field string, rule IntervalRule) Map {
+	return Map{
+		"intervals": Map{
+			field: rule.Map(),
+		},
+	}
+}
+
+// IntervalMatch 匹配分词后的词项
+// @param query 查询文本
+// @param opts option不定参数, 如WithMaxGaps、WithOrdered、WithAnalyzer、WithUseField
+func IntervalMatch(query string, opts ...Option) IntervalRule {
+	paramMap := NewOptMap(opts...)
+	paramMap["query"] = query
+	return newIntervalRule("match", paramMap)
+}
+
+// IntervalPrefix 匹配以指定前缀开头的词项
+// @param prefix 前缀
+// @param opts option不定参数, 如WithAnalyzer、WithUseField
+func IntervalPrefix(prefix string, opts ...Option) IntervalRule {
+	paramMap := NewOptMap(opts...)
+	paramMap["prefix"] = prefix
+	return newIntervalRule("prefix", paramMap)
+}
+
+// IntervalWildcard 匹配通配符表达式
+// @param pattern 通配符表达式
+// @param opts option不定参数, 如WithAnalyzer、WithUseField
+func IntervalWildcard(pattern string, opts ...Option) IntervalRule {
+	paramMap := NewOptMap(opts...)
+	paramMap["pattern"] = pattern
+	return newIntervalRule("wildcard", paramMap)
+}
+
+// IntervalFuzzy 模糊匹配词项
+// @param term 查询词
+// @param opts option不定参数, 如WithFuzziness、WithPrefixLength、WithTranspositions
+func IntervalFuzzy(term string, opts ...Option) IntervalRule {
+	paramMap := NewOptMap(opts...)
+	paramMap["term"] = term
+	return newIntervalRule("fuzzy", paramMap)
+}
+
+// IntervalAllOf 所有子规则都需匹配
+// @param rules 子规则列表
+// @param opts option不定参数, 如WithMaxGaps、WithOrdered
+func IntervalAllOf(rules []IntervalRule, opts ...Option) IntervalRule {
+	paramMap := NewOptMap(opts...)
+	paramMap["intervals"] = rules
+	return newIntervalRule("all_of", paramMap)
+}
+
+// IntervalAnyOf 任一子规则匹配即可
+// @param rules 子规则列表
+func IntervalAnyOf(rules ...IntervalRule) IntervalRule {
+	return newIntervalRule("any_of", Map{"intervals": rules})
+}
+
+// withFilter 返回附加了过滤条件的新规则
+func (r IntervalRule) withFilter(kind string, value any) IntervalRule {
+	params := maps.Clone(r.params)
+	filter := Map{}
+	if f, ok := params["filter"].(Map); ok {
+		maps.Copy(filter, f)
+	}
+	filter[kind] = value
+	params["filter"] = filter
+	return newIntervalRule(r.kind, params)
+}
+
+// Containing 匹配的区间需包含f匹配的区间
+func (r IntervalRule) Containing(f IntervalRule) IntervalRule { return r.withFilter("containing", f) }
+
+// ContainedBy 匹配的区间需被f匹配的区间包含
+func (r IntervalRule) ContainedBy(f IntervalRule) IntervalRule { return r.withFilter("contained_by", f) }
+
+// NotContaining 匹配的区间不能包含f匹配的区间
+func (r IntervalRule) NotContaining(f IntervalRule) IntervalRule {
+	return r.withFilter("not_containing", f)
+}
+
+// NotContainedBy 匹配的区间不能被f匹配的区间包含
+func (r IntervalRule) NotContainedBy(f IntervalRule) IntervalRule {
+	return r.withFilter("not_contained_by", f)
+}
+
+// Overlapping 匹配的区间需与f匹配的区间重叠
+func (r IntervalRule) Overlapping(f IntervalRule) IntervalRule { return r.withFilter("overlapping", f) }
+
+// NotOverlapping 匹配的区间不能与f匹配的区间重叠, 如"X但不靠近Y"
+func (r IntervalRule) NotOverlapping(f IntervalRule) IntervalRule {
+	return r.withFilter("not_overlapping", f)
+}
+
+// Before 匹配的区间需出现在f匹配的区间之前
+func (r IntervalRule) Before(f IntervalRule) IntervalRule { return r.withFilter("before", f) }
+
+// After 匹配的区间需出现在f匹配的区间之后
+func (r IntervalRule) After(f IntervalRule) IntervalRule { return r.withFilter("after", f) }
+
+// FilterScript 用脚本过滤匹配的区间, 脚本中可使用interval.start、interval.end、interval.gaps
+func (r IntervalRule) FilterScript(source string, opts ...Option) IntervalRule {
+	return r.withFilter("script", newScript(source, opts...))
+}
+
+// WithMaxGaps 词项之间允许的最大间隔位置数(intervals)
+// @param value 间隔数, 默认-1不限制
+func WithMaxGaps(value int) Option {
+	return func(m Map) {
+		m["max_gaps"] = value
+	}
+}
+
+// WithOrdered 词项是否需按顺序出现(intervals)
+// @param value 默认false
+func WithOrdered(value bool) Option {
+	return func(m Map) {
+		m["ordered"] = value
+	}
+}
+
+// WithUseField 改用其它字段匹配词项, 区间位置仍按当前字段计算(intervals)
+// @param value 字段名
+func WithUseField(value string) Option {
+	return func(m Map) {
+		m["use_field"] = value
+	}
+}
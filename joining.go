@@ -0,0 +1,138 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// HasChild 构造 HasChild 查询, 返回子文档满足条件的父文档
+// @param childType join字段中子文档的关系名
+// @param query 子文档上的查询
+// @param opts option不定参数, 如WithScoreMode、WithMinChildren、WithMaxChildren、WithInnerHits
+func HasChild(childType string, query Map, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["type"] = childType
+	paramMap["query"] = query
+	return Map{"has_child": paramMap}
+}
+
+// HasParent 构造 HasParent 查询, 返回父文档满足条件的子文档
+// @param parentType join字段中父文档的关系名
+// @param query 父文档上的查询
+// @param opts option不定参数, 如WithScore、WithInnerHits
+func HasParent(parentType string, query Map, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["parent_type"] = parentType
+	paramMap["query"] = query
+	return Map{"has_parent": paramMap}
+}
+
+// ParentID 构造 ParentID 查询, 返回指定父文档的子文档
+// @param childType join字段中子文档的关系名
+// @param id 父文档ID
+// @param opts option不定参数, 如WithIgnoreUnmapped
+func ParentID(childType, id string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["type"] = childType
+	paramMap["id"] = id
+	return Map{"parent_id": paramMap}
+}
+
+// InnerHits 构造inner_hits参数, 用于在父文档中返回命中的嵌套或子文档
+// @param opts option不定参数, 如WithName、WithSize、WithFrom、WithSort
+func InnerHits(opts ...Option) Map {
+	return NewOptMap(opts...)
+}
+
+// WithInnerHits 返回命中的嵌套或子文档(nested/has_child/has_parent)
+// @param innerHits InnerHits构造的参数
+func WithInnerHits(innerHits Map) Option {
+	return func(m Map) {
+		m["inner_hits"] = innerHits
+	}
+}
+
+// WithMinChildren 父文档最少需匹配的子文档数(has_child)
+// @param value 子文档数
+func WithMinChildren(value int) Option {
+	return func(m Map) {
+		m["min_children"] = value
+	}
+}
+
+// WithMaxChildren 父文档最多可匹配的子文档数(has_child)
+// @param value 子文档数
+func WithMaxChildren(value int) Option {
+	return func(m Map) {
+		m["max_children"] = value
+	}
+}
+
+// WithScore 是否将父文档的得分聚合到子文档(has_parent)
+// @param value 默认false
+func WithScore(value bool) Option {
+	return func(m Map) {
+		m["score"] = value
+	}
+}
+
+// WithName 指定名称, 如inner_hits在结果中的名称
+// @param value 名称, inner_hits默认为嵌套路径或关系名
+func WithName(value string) Option {
+	return func(m Map) {
+		m["name"] = value
+	}
+}
+
+// InnerHitsOf 将命中文档中指定名称的inner_hits解析为类型化的文档
+// @param hit 命中的文档
+// @param name inner_hits名称, 默认为嵌套路径或关系名
+func InnerHitsOf[C any, T any](hit *Hit[T], name string) ([]*Hit[C], int, error) {
+	raw, ok := hit.InnerHits[name]
+	if !ok {
+		return nil, 0, nil
+	}
+
+	var inner Result[C]
+	if err := json.Unmarshal(raw, &inner); err != nil {
+		return nil, 0, fmt.Errorf("decode inner hits %s failed: %w", name, err)
+	}
+
+	hits := make([]*Hit[C], 0, len(inner.Hits.Hits))
+	for i := range inner.Hits.Hits {
+		hits = append(hits, &inner.Hits.Hits[i])
+	}
+	return hits, inner.Hits.Total.Value, nil
+}
+
+// JoinHit 附带inner_hits文档的命中文档
+type JoinHit[T any, C any] struct {
+	*Hit[T]
+	Children map[string][]*Hit[C] // inner_hits名称到子文档的映射
+}
+
+// QueryInnerHits 查询并将各命中文档的inner_hits解析为类型化的子文档
+// 适用于nested、has_child、has_parent查询中指定了WithInnerHits的情况
+func QueryInnerHits[T any, C any](es *elasticsearch.Client, index string, queryBody any,
+) ([]*JoinHit[T, C], int, error) {
+	hits, total, err := QueryHits[T](es, index, queryBody)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*JoinHit[T, C], 0, len(hits))
+	for _, hit := range hits {
+		jh := &JoinHit[T, C]{Hit: hit, Children: map[string][]*Hit[C]{}}
+		for name := range hit.InnerHits {
+			children, _, err := InnerHitsOf[C](hit, name)
+			if err != nil {
+				return nil, 0, err
+			}
+			jh.Children[name] = children
+		}
+		results = append(results, jh)
+	}
+	return results, total, nil
+}
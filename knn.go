@@ -0,0 +1,232 @@
+package esquery
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MaxNumCandidates num_candidates的上限
+const MaxNumCandidates = 10000
+
+// KnnVector 查询向量的元素类型, float为dense_vector默认类型, int8/byte对应element_type为byte的量化向量
+type KnnVector interface {
+	~float32 | ~float64 | ~int8 | ~uint8 | ~int
+}
+
+// Knn 构造 KNN 查询, 不校验参数, 需校验时使用KnnChecked或CheckKnn
+// @param field 查询字段
+// @param vector 查询向量, 元素可为float32、float64、int8、byte, byte元素按element_type为byte的有符号值校验, 不能超过127
+// @param filter 过滤条件, 可为nil、Map、[]Map、Querier、[]Querier
+// @param opts option不定参数, 如WithTopK、WithNumCandidates、WithSimilarity、WithBoost
+func Knn[V KnnVector](field string, vector []V, filter any, opts ...Option) Map {
+	paramMap := knnParams(field, filter, opts...)
+	paramMap["query_vector"] = vector
+	// 超出范围的byte向量保留原值, 由CheckKnn报告
+	if v, err := knnVector(vector); err == nil {
+		paramMap["query_vector"] = v
+	}
+	return Map{"knn": paramMap}
+}
+
+// KnnChecked 构造 KNN 查询并校验参数, 参数同Knn
+func KnnChecked[V KnnVector](field string, vector []V, filter any, opts ...Option) (Map, error) {
+	knn := Knn(field, vector, filter, opts...)
+	if err := CheckKnn(knn); err != nil {
+		return nil, err
+	}
+	return knn, nil
+}
+
+// KnnModel 构造 KNN 查询, 查询向量由ES使用已部署的模型对文本生成, 不校验参数
+// @param field 查询字段
+// @param modelID 文本向量化模型ID
+// @param text 查询文本
+// @param filter 过滤条件, 同Knn
+// @param opts option不定参数, 如WithTopK、WithNumCandidates、WithSimilarity、WithBoost
+func KnnModel(field, modelID, text string, filter any, opts ...Option) Map {
+	paramMap := knnParams(field, filter, opts...)
+	paramMap["query_vector_builder"] = textEmbedding(modelID, text)
+	return Map{"knn": paramMap}
+}
+
+// KnnModelChecked 构造 KNN 查询并校验参数, 参数同KnnModel
+func KnnModelChecked(field, modelID, text string, filter any, opts ...Option) (Map, error) {
+	knn := KnnModel(field, modelID, text, filter, opts...)
+	if err := CheckKnn(knn); err != nil {
+		return nil, err
+	}
+	return knn, nil
+}
+
+// NestedKnn 构造嵌套向量字段的 KNN 查询, 父文档按最相似的嵌套向量打分
+// @param path 嵌套字段路径
+// @param knn Knn或KnnModel构造的查询, 字段为path下的向量字段, 过滤条件只能作用于父文档字段
+// @param innerHits 返回命中的嵌套文档, 为nil时不返回
+// @param opts Nested的option不定参数, 如WithScoreMode
+func NestedKnn(path string, knn Map, innerHits Map, opts ...Option) Map {
+	if innerHits != nil {
+		opts = append(opts, WithInnerHits(innerHits))
+	}
+	return Nested(path, knn, opts...)
+}
+
+// WithSimilarity 相似度阈值, 低于该值的文档不返回(knn)
+// @param value 阈值, 与字段的similarity度量一致, 如cosine、l2_norm
+func WithSimilarity(value float64) Option {
+	return func(m Map) {
+		m["similarity"] = value
+	}
+}
+
+// CheckKnn 校验Knn构造的查询参数, 如k不能大于num_candidates、byte向量元素不能超过127
+func CheckKnn(knn Map) error {
+	params, ok := knn["knn"].(Map)
+	if !ok {
+		return fmt.Errorf("knn query requires knn params")
+	}
+	field, _ := params["field"].(string)
+	if field == "" {
+		return fmt.Errorf("knn query requires field")
+	}
+
+	vector, hasVector := params["query_vector"]
+	_, hasBuilder := params["query_vector_builder"]
+	if hasVector == hasBuilder {
+		return fmt.Errorf("knn query on %s requires exactly one of query_vector and query_vector_builder", field)
+	}
+	if _, err := knnVector(vector); err != nil {
+		return fmt.Errorf("knn query on %s: %w", field, err)
+	}
+
+	k, hasK := params["k"].(int)
+	num, hasNum := params["num_candidates"].(int)
+	if hasK && k <= 0 {
+		return fmt.Errorf("knn query on %s has invalid k %d", field, k)
+	}
+	if hasNum && (num <= 0 || num > MaxNumCandidates) {
+		return fmt.Errorf("knn query on %s has invalid num_candidates %d", field, num)
+	}
+	if hasK && hasNum && k > num {
+		return fmt.Errorf("knn query on %s has k %d greater than num_candidates %d", field, k, num)
+	}
+	return nil
+}
+
+// KnnQuery 类型化的 KNN 查询, 生成DSL时校验参数
+type KnnQuery struct {
+	Field         string    // 查询字段
+	Vector        any       // 查询向量, 如[]float32、[]int8
+	ModelID       string    // 文本向量化模型ID, 与Vector二选一
+	ModelText     string    // 查询文本
+	K             int       // 返回的最近邻个数
+	NumCandidates int       // 每个分片的候选文档个数
+	Similarity    float64   // 相似度阈值
+	Filter        []Querier // 过滤条件
+	Boost         float64   // 权重
+}
+
+// Source 生成查询DSL
+func (q KnnQuery) Source() (any, error) {
+	var opts []Option
+	if q.K > 0 {
+		opts = append(opts, WithTopK(q.K))
+	}
+	if q.NumCandidates > 0 {
+		opts = append(opts, WithNumCandidates(q.NumCandidates))
+	}
+	if q.Similarity != 0 {
+		opts = append(opts, WithSimilarity(q.Similarity))
+	}
+	if q.Boost != 0 {
+		opts = append(opts, WithBoost(q.Boost))
+	}
+
+	paramMap := knnParams(q.Field, q.Filter, opts...)
+	if q.Vector != nil {
+		v, err := knnVector(q.Vector)
+		if err != nil {
+			return nil, fmt.Errorf("knn query on %s: %w", q.Field, err)
+		}
+		paramMap["query_vector"] = v
+	}
+	if q.ModelID != "" {
+		paramMap["query_vector_builder"] = textEmbedding(q.ModelID, q.ModelText)
+	}
+	knn := Map{"knn": paramMap}
+	if err := CheckKnn(knn); err != nil {
+		return nil, err
+	}
+	return knn, nil
+}
+
+// MarshalJSON json序列化
+func (q KnnQuery) MarshalJSON() ([]byte, error) { return marshalQuery(q) }
+
+// knnParams 构造knn的公共参数
+func knnParams(field string, filter any, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["field"] = field
+	if f := knnFilter(filter); f != nil {
+		paramMap["filter"] = f
+	}
+	return paramMap
+}
+
+// textEmbedding 构造由模型生成查询向量的query_vector_builder
+func textEmbedding(modelID, text string) Map {
+	return Map{
+		"text_embedding": Map{
+			"model_id":   modelID,
+			"model_text": text,
+		},
+	}
+}
+
+// knnVector 转换查询向量, 元素为uint8的切片(含自定义类型)默认序列化为base64, int8、uint8元素统一转为[]int,
+// uint8元素按有符号的byte向量校验范围
+func knnVector(vector any) (any, error) {
+	rv := reflect.ValueOf(vector)
+	if rv.Kind() != reflect.Slice {
+		return vector, nil
+	}
+	switch rv.Type().Elem().Kind() {
+	case reflect.Uint8:
+		ints := make([]int, rv.Len())
+		for i := range ints {
+			b := rv.Index(i).Uint()
+			if b > 127 {
+				return nil, fmt.Errorf("byte vector element %d at %d out of range [-128, 127]", b, i)
+			}
+			ints[i] = int(b)
+		}
+		return ints, nil
+	case reflect.Int8:
+		ints := make([]int, rv.Len())
+		for i := range ints {
+			ints[i] = int(rv.Index(i).Int())
+		}
+		return ints, nil
+	}
+	return vector, nil
+}
+
+// knnFilter 统一过滤条件的格式, 无过滤条件时返回nil
+func knnFilter(filter any) any {
+	switch f := filter.(type) {
+	case nil:
+		return nil
+	case Map:
+		if len(f) == 0 {
+			return nil
+		}
+	case []Map:
+		if len(f) == 0 {
+			return nil
+		}
+	case []Querier:
+		if len(f) == 0 {
+			return nil
+		}
+	}
+	return filter
+}
@@ -0,0 +1,420 @@
+package esquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// IndexMapping 索引映射中与查询改写相关的信息
+type IndexMapping struct {
+	Types    map[string]string // 字段完整路径到字段类型, 如 "items.sku": "keyword"
+	Nested   []string          // nested类型的字段路径, 按路径排序
+	Keywords map[string]string // text字段到其keyword子字段, 如 "name": "name.keyword"
+}
+
+// ParseMapping 解析索引映射, 支持GET _mapping的返回(可包含多个索引)、mappings或properties
+func ParseMapping(data []byte) (*IndexMapping, error) {
+	var root Map
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("decode mapping failed: %w", err)
+	}
+
+	im := &IndexMapping{Types: map[string]string{}, Keywords: map[string]string{}}
+	switch {
+	case root["properties"] != nil:
+		im.walk("", root)
+	case root["mappings"] != nil:
+		mappings, _ := root["mappings"].(Map)
+		im.walk("", mappings)
+	default:
+		for index, v := range root {
+			body, _ := v.(Map)
+			mappings, ok := body["mappings"].(Map)
+			if !ok {
+				return nil, fmt.Errorf("mapping of %s has no mappings", index)
+			}
+			im.walk("", mappings)
+		}
+	}
+	sort.Strings(im.Nested)
+	im.Nested = slices.Compact(im.Nested)
+	return im, nil
+}
+
+// walk 遍历properties, 记录字段类型、nested路径和keyword子字段
+func (im *IndexMapping) walk(prefix string, node Map) {
+	props, _ := node["properties"].(Map)
+	for name, v := range props {
+		field, _ := v.(Map)
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		ftype, _ := field["type"].(string)
+		if ftype == "" {
+			ftype = "object"
+		}
+		im.Types[path] = ftype
+		if ftype == "nested" {
+			im.Nested = append(im.Nested, path)
+		}
+		if ftype == "object" || ftype == "nested" {
+			im.walk(path, field)
+			continue
+		}
+
+		subs, _ := field["fields"].(Map)
+		for sub, sv := range subs {
+			subField, _ := sv.(Map)
+			subType, _ := subField["type"].(string)
+			im.Types[path+"."+sub] = subType
+			if ftype == "text" && subType == "keyword" {
+				if _, ok := im.Keywords[path]; !ok || sub == "keyword" {
+					im.Keywords[path] = path + "." + sub
+				}
+			}
+		}
+	}
+}
+
+// GetMapping 查询索引映射
+func GetMapping(es *elasticsearch.Client, index string) (*IndexMapping, error) {
+	res, err := es.Indices.GetMapping(
+		es.Indices.GetMapping.WithContext(context.TODO()),
+		es.Indices.GetMapping.WithIndex(index),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("es get mapping failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read mapping failed: %w", err)
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("es get mapping error: %s", data)
+	}
+	return ParseMapping(data)
+}
+
+// NestedPath 字段所属的最内层nested路径, 不属于nested字段时返回空
+func (im *IndexMapping) NestedPath(field string) string {
+	path := ""
+	for _, p := range im.Nested {
+		if strings.HasPrefix(field, p+".") && len(p) > len(path) {
+			path = p
+		}
+	}
+	return path
+}
+
+// KeywordField 返回text字段的keyword子字段, 没有时返回原字段
+func (im *IndexMapping) KeywordField(field string) string {
+	if kw, ok := im.Keywords[field]; ok {
+		return kw
+	}
+	return field
+}
+
+// nextLevel 返回ctx与path之间最外层的nested路径
+func (im *IndexMapping) nextLevel(ctx, path string) string {
+	for _, p := range im.Nested {
+		if isUnder(p, ctx) && (p == path || strings.HasPrefix(path, p+".")) {
+			return p
+		}
+	}
+	return path
+}
+
+// isUnder 判断path是否在ctx之下, ctx为空表示根文档
+func isUnder(path, ctx string) bool {
+	return ctx == "" || strings.HasPrefix(path, ctx+".")
+}
+
+// 字段名为参数key的查询类型
+var keyedQueries = []string{
+	"term", "terms", "terms_set", "match", "match_phrase", "match_phrase_prefix", "match_bool_prefix",
+	"prefix", "wildcard", "regexp", "fuzzy", "range", "intervals", "span_term",
+	"geo_distance", "geo_bounding_box", "geo_polygon", "geo_shape",
+}
+
+// keyedOptions 字段名为key的查询中不是字段名的参数
+var keyedOptions = []string{"boost", "_name", "distance", "distance_type", "validation_method", "type", "ignore_unmapped"}
+
+// ApplyMapping 按索引映射改写查询
+// nested字段的子句按路径分组并包装为Nested查询(支持多层嵌套), must/filter中同一路径的子句放入同一个Nested, 匹配同一嵌套对象
+// Term、Terms查询的text字段改为其keyword子字段
+// 同一子句引用了不同nested路径的字段, 或Nested查询中引用了路径外的字段时返回错误
+// @param query 查询语句
+// @param mapping 索引映射, 由GetMapping或ParseMapping获取
+func ApplyMapping(query Map, mapping *IndexMapping) (Map, error) {
+	m, path, err := mapping.rewrite(query, "")
+	if err != nil {
+		return nil, err
+	}
+	return mapping.wrap("must", []nestedClause{{m, path}}, "")[0], nil
+}
+
+// nestedClause 改写后的子句及其需要的nested路径
+type nestedClause struct {
+	query Map
+	path  string
+}
+
+// rewrite 改写查询, 返回尚未包装的nested路径
+// @param ctx 当前所在的nested路径, 根文档为空
+func (im *IndexMapping) rewrite(query Map, ctx string) (Map, string, error) {
+	if len(query) != 1 {
+		return query, ctx, nil
+	}
+	var qtype string
+	var body Map
+	for k, v := range query {
+		qtype = k
+		body, _ = v.(Map)
+	}
+	if body == nil {
+		return query, ctx, nil
+	}
+
+	switch qtype {
+	case "bool":
+		return im.rewriteBool(body, ctx)
+	case "nested":
+		path, _ := body["path"].(string)
+		if !slices.Contains(im.Nested, path) {
+			return nil, "", fmt.Errorf("nested path %s is not a nested field", path)
+		}
+		if !isUnder(path, ctx) {
+			return nil, "", fmt.Errorf("nested path %s is outside nested path %s", path, ctx)
+		}
+		inner, _ := body["query"].(Map)
+		sub, err := im.rewriteWrapped(inner, path)
+		if err != nil {
+			return nil, "", err
+		}
+		out := maps.Clone(body)
+		out["query"] = sub
+		// 跨越多层时补齐中间层
+		return im.wrapBetween(Map{"nested": out}, ctx, path), ctx, nil
+	case "constant_score":
+		return im.rewriteChild(qtype, body, "filter", ctx)
+	case "function_score", "script_score":
+		return im.rewriteChild(qtype, body, "query", ctx)
+	case "boosting":
+		q, _, err := im.rewriteChild(qtype, body, "positive", ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		return im.rewriteChild(qtype, q[qtype].(Map), "negative", ctx)
+	case "dis_max":
+		queries, err := toClauses(body["queries"])
+		if err != nil {
+			return nil, "", err
+		}
+		out := maps.Clone(body)
+		list := make([]Map, 0, len(queries))
+		for _, q := range queries {
+			sub, err := im.rewriteWrapped(q, ctx)
+			if err != nil {
+				return nil, "", err
+			}
+			list = append(list, sub)
+		}
+		out["queries"] = list
+		return Map{qtype: out}, ctx, nil
+	case "has_child", "has_parent":
+		return query, ctx, nil
+	}
+
+	return im.rewriteLeaf(qtype, body, ctx)
+}
+
+// rewriteChild 改写复合查询中的单个子查询
+func (im *IndexMapping) rewriteChild(qtype string, body Map, key, ctx string) (Map, string, error) {
+	inner, ok := body[key].(Map)
+	if !ok {
+		return Map{qtype: body}, ctx, nil
+	}
+	sub, err := im.rewriteWrapped(inner, ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	out := maps.Clone(body)
+	out[key] = sub
+	return Map{qtype: out}, ctx, nil
+}
+
+// rewriteWrapped 改写查询并包装到ctx路径
+func (im *IndexMapping) rewriteWrapped(query Map, ctx string) (Map, error) {
+	m, path, err := im.rewrite(query, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return im.wrap("must", []nestedClause{{m, path}}, ctx)[0], nil
+}
+
+// rewriteBool 改写Bool查询, 子句按nested路径分组包装
+func (im *IndexMapping) rewriteBool(body Map, ctx string) (Map, string, error) {
+	out := maps.Clone(body)
+	for _, key := range boolClauses {
+		children, err := toClauses(body[key])
+		if err != nil {
+			return nil, "", fmt.Errorf("bool %s: %w", key, err)
+		}
+		if len(children) == 0 {
+			continue
+		}
+
+		items := make([]nestedClause, 0, len(children))
+		for _, child := range children {
+			m, path, err := im.rewrite(child, ctx)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, nestedClause{m, path})
+		}
+		out[key] = im.wrap(key, items, ctx)
+	}
+	return Map{"bool": out}, ctx, nil
+}
+
+// rewriteLeaf 改写叶子查询, 返回其字段所属的nested路径
+func (im *IndexMapping) rewriteLeaf(qtype string, body Map, ctx string) (Map, string, error) {
+	out := body
+	if qtype == "term" || qtype == "terms" {
+		out = Map{}
+		for k, v := range body {
+			if !slices.Contains(keyedOptions, k) {
+				k = im.KeywordField(k)
+			}
+			out[k] = v
+		}
+	}
+
+	fields := leafFields(qtype, out)
+	if len(fields) == 0 {
+		return Map{qtype: out}, ctx, nil
+	}
+	path := ""
+	for i, field := range fields {
+		p := im.NestedPath(field)
+		if i > 0 && p != path {
+			return nil, "", fmt.Errorf("%s query mixes nested paths %q and %q", qtype, path, p)
+		}
+		path = p
+	}
+	if path != ctx && !isUnder(path, ctx) {
+		return nil, "", fmt.Errorf("%s query on %s is outside nested path %s", qtype, fields[0], ctx)
+	}
+	return Map{qtype: out}, path, nil
+}
+
+// leafFields 叶子查询引用的字段, 忽略含通配符的字段
+func leafFields(qtype string, body Map) []string {
+	var fields []string
+	switch {
+	case slices.Contains(keyedQueries, qtype):
+		for k := range body {
+			if !slices.Contains(keyedOptions, k) {
+				fields = append(fields, k)
+			}
+		}
+	case body["field"] != nil:
+		if f, ok := body["field"].(string); ok {
+			fields = append(fields, f)
+		}
+	case body["fields"] != nil:
+		list, _ := toStrings(body["fields"])
+		fields = append(fields, list...)
+	}
+
+	out := fields[:0]
+	for _, f := range fields {
+		if !strings.Contains(f, "*") {
+			// 去掉权重后缀, 如 "title^2"
+			f, _, _ = strings.Cut(f, "^")
+			out = append(out, f)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// toStrings 将[]string或[]any转为[]string
+func toStrings(v any) ([]string, bool) {
+	switch list := v.(type) {
+	case []string:
+		return list, true
+	case []any:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// wrap 将子句包装到ctx路径, must/filter中同一路径的子句放入同一个Nested
+func (im *IndexMapping) wrap(key string, items []nestedClause, ctx string) []Map {
+	out := make([]Map, 0, len(items))
+	var levels []string
+	groups := map[string][]nestedClause{}
+	for _, it := range items {
+		if it.path == ctx {
+			out = append(out, it.query)
+			continue
+		}
+		level := im.nextLevel(ctx, it.path)
+		if key != "must" && key != "filter" {
+			out = append(out, im.nest(key, level, im.wrap(key, []nestedClause{it}, level)))
+			continue
+		}
+		if _, ok := groups[level]; !ok {
+			levels = append(levels, level)
+		}
+		groups[level] = append(groups[level], it)
+	}
+
+	for _, level := range levels {
+		out = append(out, im.nest(key, level, im.wrap(key, groups[level], level)))
+	}
+	return out
+}
+
+// nest 构造Nested查询, 多个子句时按原子句类型组合为Bool
+func (im *IndexMapping) nest(key, path string, queries []Map) Map {
+	if len(queries) == 1 {
+		return Nested(path, queries[0])
+	}
+	return Nested(path, Bool(func(m Map) { m[key] = queries }))
+}
+
+// wrapBetween 为跨越多层的Nested查询补齐ctx与path之间的中间层
+func (im *IndexMapping) wrapBetween(query Map, ctx, path string) Map {
+	var levels []string
+	for _, p := range im.Nested {
+		if p != path && isUnder(p, ctx) && strings.HasPrefix(path, p+".") {
+			levels = append(levels, p)
+		}
+	}
+	for i := len(levels) - 1; i >= 0; i-- {
+		query = Nested(levels[i], query)
+	}
+	return query
+}
@@ -0,0 +1,102 @@
+package esquery
+
+import "github.com/elastic/go-elasticsearch/v8"
+
+// MoreLikeThis 构造 MoreLikeThis 查询, 查找与给定文本或文档相似的文档
+// @param fields 提取关键词的字段, 为nil时使用索引的默认字段
+// @param like 参照内容, 可混合文本、LikeDoc、LikeArtificial
+// @param opts option不定参数, 如WithUnlike、WithMinTermFreq、WithMaxQueryTerms、WithMinDocFreq、WithBoostTerms
+func MoreLikeThis(fields []string, like []any, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	if len(fields) > 0 {
+		paramMap["fields"] = fields
+	}
+	paramMap["like"] = like
+	return Map{"more_like_this": paramMap}
+}
+
+// LikeDoc 引用已索引的文档作为参照
+// @param index 文档所在索引
+// @param id 文档ID
+func LikeDoc(index, id string) Map {
+	return Map{"_index": index, "_id": id}
+}
+
+// LikeArtificial 使用未索引的人工文档作为参照
+// @param index 用于解析字段映射的索引
+// @param doc 文档内容
+func LikeArtificial(index string, doc any) Map {
+	return Map{"_index": index, "doc": doc}
+}
+
+// WithUnlike 与之相似的文档降低排名, 格式同like
+// @param unlike 文本、LikeDoc或LikeArtificial
+func WithUnlike(unlike ...any) Option {
+	return func(m Map) {
+		m["unlike"] = unlike
+	}
+}
+
+// WithMinTermFreq 参照内容中词频低于该值的词被忽略(more_like_this)
+// @param value 词频, 默认2
+func WithMinTermFreq(value int) Option {
+	return func(m Map) {
+		m["min_term_freq"] = value
+	}
+}
+
+// WithMaxQueryTerms 最多选取的关键词个数(more_like_this)
+// @param value 个数, 默认25
+func WithMaxQueryTerms(value int) Option {
+	return func(m Map) {
+		m["max_query_terms"] = value
+	}
+}
+
+// WithMinDocFreq 文档频率低于该值的词被忽略(more_like_this)
+// @param value 文档数, 默认5
+func WithMinDocFreq(value int) Option {
+	return func(m Map) {
+		m["min_doc_freq"] = value
+	}
+}
+
+// WithMaxDocFreq 文档频率高于该值的词被忽略, 用于排除高频词(more_like_this)
+// @param value 文档数, 默认不限制
+func WithMaxDocFreq(value int) Option {
+	return func(m Map) {
+		m["max_doc_freq"] = value
+	}
+}
+
+// WithBoostTerms 按关键词的tf-idf值加权(more_like_this)
+// @param value 权重系数, 默认0不加权
+func WithBoostTerms(value float64) Option {
+	return func(m Map) {
+		m["boost_terms"] = value
+	}
+}
+
+// WithInclude 结果中是否包含参照文档本身(more_like_this)
+// @param value 默认false
+func WithInclude(value bool) Option {
+	return func(m Map) {
+		m["include"] = value
+	}
+}
+
+// QuerySimilar 查询与指定文档相似的文档, 结果中排除该文档本身
+// @param id 参照文档ID
+// @param fields 提取关键词的字段
+// @param size 返回的记录数
+// @param opts MoreLikeThis的option不定参数
+func QuerySimilar[T any](es *elasticsearch.Client, index, id string, fields []string, size int, opts ...Option,
+) ([]*T, []string, error) {
+	mlt := MoreLikeThis(fields, []any{LikeDoc(index, id)}, opts...)
+	esQuery := &ESQuery{
+		Query: Bool(WithMust(mlt), WithMustNot(IDs(id))),
+		Size:  size,
+	}
+	l, _, _, ids, err := QueryWithMeta[T](es, index, esQuery)
+	return l, ids, err
+}
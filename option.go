@@ -139,6 +139,103 @@ func WithType(value string) Option {
 	}
 }
 
+// WithSlop 短语中词项之间允许间隔的位置数(match_phrase/query_string)
+// @param value 间隔数, 默认0
+func WithSlop(value int) Option {
+	return func(m Map) {
+		m["slop"] = value
+	}
+}
+
+// WithMaxExpansions 前缀或模糊匹配时最多扩展的词项数
+// @param value 词项数, 默认50
+func WithMaxExpansions(value int) Option {
+	return func(m Map) {
+		m["max_expansions"] = value
+	}
+}
+
+// WithDefaultOperator 未指定操作符时词与词之间的逻辑关系(query_string/simple_query_string)
+// @param value AND/OR(默认)
+func WithDefaultOperator(value string) Option {
+	return func(m Map) {
+		m["default_operator"] = value
+	}
+}
+
+// WithDefaultField 未指定字段时查询的默认字段(query_string)
+// @param value 字段名, 默认为索引设置的index.query.default_field
+func WithDefaultField(value string) Option {
+	return func(m Map) {
+		m["default_field"] = value
+	}
+}
+
+// WithAnalyzeWildcard 是否对通配符查询词进行分词
+// @param value 默认false
+func WithAnalyzeWildcard(value bool) Option {
+	return func(m Map) {
+		m["analyze_wildcard"] = value
+	}
+}
+
+// WithAllowLeadingWildcard 是否允许*或?作为查询词的首字符(query_string), 开启后查询代价很高
+// @param value 默认true
+func WithAllowLeadingWildcard(value bool) Option {
+	return func(m Map) {
+		m["allow_leading_wildcard"] = value
+	}
+}
+
+// WithLenient 是否忽略类型不匹配的错误, 如对数值字段查询文本
+// @param value 默认false
+func WithLenient(value bool) Option {
+	return func(m Map) {
+		m["lenient"] = value
+	}
+}
+
+// simple_query_string的flags枚举值, 多个值用|连接
+var (
+	FlagAll        = "ALL"        // 启用全部操作符(默认)
+	FlagNone       = "NONE"       // 禁用全部操作符
+	FlagAnd        = "AND"        // + 与
+	FlagOr         = "OR"         // | 或
+	FlagNot        = "NOT"        // - 非
+	FlagPrefix     = "PREFIX"     // * 前缀
+	FlagPhrase     = "PHRASE"     // "" 短语
+	FlagPrecedence = "PRECEDENCE" // () 优先级
+	FlagEscape     = "ESCAPE"     // \ 转义
+	FlagWhitespace = "WHITESPACE" // 空白符分隔
+	FlagFuzzy      = "FUZZY"      // ~N 模糊
+	FlagNear       = "NEAR"       // ~N 邻近
+	FlagSlop       = "SLOP"       // ~N 短语间隔
+)
+
+// WithFlags 启用的操作符(simple_query_string)
+// @param value 如 "OR|AND|PREFIX"
+func WithFlags(value string) Option {
+	return func(m Map) {
+		m["flags"] = value
+	}
+}
+
+// WithQuoteFieldSuffix 对引号内的短语改用带该后缀的字段查询, 如 ".exact"
+// @param value 字段后缀
+func WithQuoteFieldSuffix(value string) Option {
+	return func(m Map) {
+		m["quote_field_suffix"] = value
+	}
+}
+
+// WithAutoGenerateSynonymsPhraseQuery 是否为多词同义词自动生成短语查询
+// @param value 默认true
+func WithAutoGenerateSynonymsPhraseQuery(value bool) Option {
+	return func(m Map) {
+		m["auto_generate_synonyms_phrase_query"] = value
+	}
+}
+
 // format 日期格式少量枚举
 var (
 	Format1      = "yyyy-MM-dd"          // "2024-03-14"
@@ -232,7 +329,7 @@ func WithValidationMethod(value string) Option {
 // @param value 候选个数
 func WithNumCandidates(value int) Option {
 	return func(m Map) {
-		m["numCandidates"] = value
+		m["num_candidates"] = value
 	}
 }
 
@@ -286,7 +383,7 @@ func WithShardSize(shardSize int) Option {
 
 // Range 聚合
 
-// WithRanges 设置数值范围聚合的区间
+// WithRanges 设置范围聚合的区间(range、date_range、ip_range), 区间可用AggRange、AggMaskRange构造
 func WithRanges(ranges []Map) Option {
 	return func(m Map) {
 		m["ranges"] = ranges
@@ -357,7 +454,8 @@ func WithPath(path string) Option {
 
 // Adjacency Matrix 聚合
 
-// WithFilters 设置邻接矩阵聚合的过滤条件
+// WithFilters 设置邻接矩阵聚合的过滤条件(adjacency_matrix), 过滤条件名称到查询的映射
+// filter、filters聚合使用FilterAgg、FiltersAgg构造
 func WithFilters(filters Map) Option {
 	return func(m Map) {
 		m["filters"] = filters
@@ -0,0 +1,219 @@
+package esquery
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MaxResultWindow from+size的上限, 超过后改用search_after翻页, 与索引的max_result_window默认值一致
+var MaxResultWindow = 10000
+
+// DefaultPageSize 未指定Size时的每页记录数, 与ES默认值一致
+const DefaultPageSize = 10
+
+// 分页错误
+var (
+	ErrFirstPage     = errors.New("already at the first page")
+	ErrLastPage      = errors.New("already at the last page")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// NextPage 根据当前查询及其返回的记录构造下一页查询
+// 浅分页使用from/size, 超过MaxResultWindow后使用上一页最后一条记录的排序值作为search_after,
+// 此时DSL必须指定排序, 且排序字段应能唯一确定文档顺序(如追加_id或唯一字段)
+// @param q 当前页的查询
+// @param hits 当前页返回的记录, 需保留排序值, 可由QueryHits获取
+func NextPage[T any](q *Query, hits []*Hit[T]) (*Query, error) {
+	if q == nil || q.DSL == nil {
+		return nil, fmt.Errorf("paginate query without dsl")
+	}
+	if len(hits) == 0 || len(hits) < pageSize(q.DSL) {
+		return nil, ErrLastPage
+	}
+
+	// Cursors[i]记录第i页最后一条记录的排序值, 缺少前面页的记录时无法回到上一页
+	if len(q.Cursors) < q.Page {
+		return nil, fmt.Errorf("%w: page %d has only %d cursors", ErrInvalidCursor, q.Page, len(q.Cursors))
+	}
+	next := q.clone()
+	next.Cursors = append(next.Cursors[:q.Page], hits[len(hits)-1].Sort)
+	next.Page = q.Page + 1
+	if err := next.applyPage(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// PrevPage 根据当前查询构造上一页查询
+// @param q 当前页的查询
+func PrevPage(q *Query) (*Query, error) {
+	if q == nil || q.DSL == nil {
+		return nil, fmt.Errorf("paginate query without dsl")
+	}
+	if q.Page <= 0 {
+		return nil, ErrFirstPage
+	}
+
+	prev := q.clone()
+	prev.Page = q.Page - 1
+	prev.Cursors = prev.Cursors[:min(prev.Page, len(prev.Cursors))]
+	if err := prev.applyPage(); err != nil {
+		return nil, err
+	}
+	return prev, nil
+}
+
+// clone 复制查询信息, DSL中仅分页字段会被修改, 其余条件共享
+func (q *Query) clone() *Query {
+	c := *q
+	dsl := *q.DSL
+	c.DSL = &dsl
+	c.Cursors = slices.Clone(q.Cursors)
+	return &c
+}
+
+// applyPage 按页码设置DSL的分页参数
+func (q *Query) applyPage() error {
+	size := pageSize(q.DSL)
+	from := q.Page * size
+	// 第一页没有上一页的排序值, 总是从0开始
+	if q.Page == 0 || from+size <= MaxResultWindow {
+		q.DSL.From = from
+		q.DSL.SearchAfter = nil
+		return nil
+	}
+
+	if len(q.DSL.Sort) == 0 {
+		return fmt.Errorf("page %d exceeds max result window and search_after requires sort", q.Page)
+	}
+	if len(q.Cursors) < q.Page || len(q.Cursors[q.Page-1]) == 0 {
+		return fmt.Errorf("page %d exceeds max result window but previous page has no sort values", q.Page)
+	}
+	q.DSL.From = 0
+	q.DSL.SearchAfter = q.Cursors[q.Page-1]
+	return nil
+}
+
+// pageSize 每页记录数
+func pageSize(dsl *ESQuery) int {
+	if dsl.Size > 0 {
+		return dsl.Size
+	}
+	return DefaultPageSize
+}
+
+// pageCursor 游标内容, 只包含查询的摘要和分页状态, 不包含DSL
+type pageCursor struct {
+	Hash    string  `json:"h"`           // 查询摘要, 用于校验游标与查询是否一致
+	Page    int     `json:"p,omitempty"` // 当前页码
+	Cursors [][]any `json:"c,omitempty"` // 已访问各页最后一条记录的排序值
+}
+
+// EncodeCursor 将查询的分页状态序列化为不透明的游标, 带HMAC签名防篡改, 可交给前端在翻页时回传
+// 游标只包含查询摘要、页码和search_after的排序值, 不暴露DSL, 排序值本身仍为明文
+// @param q 查询信息
+// @param secret 签名密钥
+func EncodeCursor(q *Query, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", fmt.Errorf("cursor secret is empty")
+	}
+	if q == nil || q.DSL == nil {
+		return "", fmt.Errorf("encode cursor without dsl")
+	}
+
+	hash, err := queryHash(q)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(pageCursor{Hash: hash, Page: q.Page, Cursors: q.Cursors})
+	if err != nil {
+		return "", fmt.Errorf("marshal cursor failed: %w", err)
+	}
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(cursorSign(payload, secret)), nil
+}
+
+// DecodeCursor 校验签名, 将游标中的分页状态恢复到查询上
+// @param token EncodeCursor生成的游标
+// @param secret 签名密钥
+// @param q 服务端按相同条件重新构造的查询, 须与生成游标时的查询一致(分页参数除外)
+func DecodeCursor(token string, secret []byte, q *Query) (*Query, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cursor secret is empty")
+	}
+	if q == nil || q.DSL == nil {
+		return nil, fmt.Errorf("decode cursor without dsl")
+	}
+
+	enc := base64.RawURLEncoding
+	data, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, cursorSign(payload, secret)) {
+		return nil, ErrInvalidCursor
+	}
+
+	// 保留数字原样, 避免long类型的排序值丢失精度
+	var c pageCursor
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	hash, err := queryHash(q)
+	if err != nil {
+		return nil, err
+	}
+	if c.Hash != hash {
+		return nil, fmt.Errorf("%w: cursor does not match query", ErrInvalidCursor)
+	}
+	if c.Page < 0 || len(c.Cursors) < c.Page {
+		return nil, fmt.Errorf("%w: page %d has only %d cursors", ErrInvalidCursor, c.Page, len(c.Cursors))
+	}
+
+	out := q.clone()
+	out.Page = c.Page
+	out.Cursors = c.Cursors
+	if err := out.applyPage(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// queryHash 计算查询的摘要, 不包含分页参数
+func queryHash(q *Query) (string, error) {
+	dsl := *q.DSL
+	dsl.From = 0
+	dsl.SearchAfter = nil
+	data, err := json.Marshal(struct {
+		Index string   `json:"index"`
+		DSL   *ESQuery `json:"dsl"`
+	}{q.Index, &dsl})
+	if err != nil {
+		return "", fmt.Errorf("marshal cursor query failed: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:16]), nil
+}
+
+// cursorSign 计算游标签名
+func cursorSign(payload, secret []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
@@ -0,0 +1,182 @@
+package esquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// percolatorSlotField 命中结果中记录匹配文档下标的字段前缀
+const percolatorSlotField = "_percolator_document_slot"
+
+// PercolatorDoc 构造存入percolator字段的文档, 即保存的查询及其元信息
+// @param field percolator类型的字段
+// @param q 保存的查询, 使用其Query部分
+// @param meta 元信息, 如查询名称、订阅用户等
+func PercolatorDoc(field string, q *ESQuery, meta Map) (Map, error) {
+	if q == nil || q.Query == nil {
+		return nil, fmt.Errorf("percolator %s requires query", field)
+	}
+	doc := maps.Clone(meta)
+	if doc == nil {
+		doc = Map{}
+	}
+	doc[field] = q.Query
+	return doc, nil
+}
+
+// IndexPercolator 保存查询到percolator索引
+// @param index percolator索引, 需包含percolator字段及查询涉及字段的映射
+// @param id 保存的查询ID
+// @param field percolator类型的字段
+// @param q 保存的查询
+// @param meta 元信息
+func IndexPercolator(es *elasticsearch.Client, index, id, field string, q *ESQuery, meta Map) error {
+	doc, err := PercolatorDoc(field, q, meta)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal percolator failed: %w", err)
+	}
+
+	res, err := es.Index(index, bytes.NewReader(body),
+		es.Index.WithContext(context.TODO()),
+		es.Index.WithDocumentID(id),
+	)
+	if err != nil {
+		return fmt.Errorf("es index failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		data, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es index error: %s", data)
+	}
+	return nil
+}
+
+// Percolate 构造 Percolate 查询, 返回与传入文档匹配的已保存查询
+// @param field percolator类型的字段
+// @param docs 一个或多个待匹配的文档
+func Percolate(field string, docs ...any) Map {
+	paramMap := Map{"field": field}
+	if len(docs) == 1 {
+		paramMap["document"] = docs[0]
+	} else {
+		paramMap["documents"] = docs
+	}
+	return Map{"percolate": paramMap}
+}
+
+// DocRef 已索引文档的引用
+type DocRef struct {
+	Name    string // 结果中区分该文档的名称, 默认为ID
+	Index   string // 文档所在的索引
+	ID      string // 文档ID
+	Routing string // 路由值
+}
+
+// PercolateRefs 构造 Percolate 查询, 待匹配的文档引用已索引的文档
+// 多个文档时每个文档生成一个命名的percolate查询, 结果按名称区分
+// @param field percolator类型的字段
+// @param refs 一个或多个文档引用
+func PercolateRefs(field string, refs ...DocRef) Map {
+	queries := make([]Map, 0, len(refs))
+	for _, ref := range refs {
+		name := ref.Name
+		if name == "" {
+			name = ref.ID
+		}
+		paramMap := Map{"field": field, "index": ref.Index, "id": ref.ID, "name": name}
+		if ref.Routing != "" {
+			paramMap["routing"] = ref.Routing
+		}
+		queries = append(queries, Map{"percolate": paramMap})
+	}
+
+	if len(queries) == 1 {
+		return queries[0]
+	}
+	return Bool(WithShould(queries))
+}
+
+// PercolateMatch 匹配到的已保存查询
+type PercolateMatch[T any] struct {
+	ID         string           `json:"id"`          // 保存的查询ID
+	Query      *T               `json:"query"`       // 保存的查询文档, 包含查询及元信息
+	Score      float64          `json:"score"`       // 得分
+	Slots      []int            `json:"slots"`       // 匹配的文档在传入列表中的下标
+	NamedSlots map[string][]int `json:"named_slots"` // 命名的percolate查询中匹配的文档下标, 如PercolateRefs
+}
+
+// ParsePercolate 从命中文档中解析匹配到的已保存查询
+// 命中结果未返回下标字段时Slots和NamedSlots为空, 不推断匹配的文档
+func ParsePercolate[T any](hits []*Hit[T]) ([]*PercolateMatch[T], error) {
+	matches := make([]*PercolateMatch[T], 0, len(hits))
+	for _, hit := range hits {
+		pm := &PercolateMatch[T]{ID: hit.ID, Query: hit.Source, Score: hit.Score, NamedSlots: map[string][]int{}}
+		for key, raw := range hit.Fields {
+			if !strings.HasPrefix(key, percolatorSlotField) {
+				continue
+			}
+
+			var slots []int
+			if err := json.Unmarshal(raw, &slots); err != nil {
+				return nil, fmt.Errorf("decode %s of %s failed: %w", key, hit.ID, err)
+			}
+			if key == percolatorSlotField {
+				pm.Slots = slots
+			} else {
+				pm.NamedSlots[strings.TrimPrefix(key, percolatorSlotField+"_")] = slots
+			}
+		}
+
+		matches = append(matches, pm)
+	}
+	return matches, nil
+}
+
+// QueryPercolate 执行percolate查询, 返回匹配到的已保存查询
+func QueryPercolate[T any](es *elasticsearch.Client, index string, queryBody any) ([]*PercolateMatch[T], error) {
+	hits, _, err := QueryHits[T](es, index, queryBody)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePercolate(hits)
+}
+
+// PercolateSlot 传入文档的位置
+type PercolateSlot struct {
+	Name string // 命名的percolate查询名称, 如PercolateRefs的DocRef.Name, 未命名时为空
+	Slot int    // 文档在该percolate查询传入列表中的下标
+}
+
+// GroupBySlot 按传入文档的位置分组匹配到的已保存查询ID, 包含命名的percolate查询
+func GroupBySlot[T any](matches []*PercolateMatch[T]) map[PercolateSlot][]string {
+	groups := map[PercolateSlot][]string{}
+	for _, m := range matches {
+		for _, slot := range m.Slots {
+			key := PercolateSlot{Slot: slot}
+			groups[key] = append(groups[key], m.ID)
+		}
+		for name, slots := range m.NamedSlots {
+			for _, slot := range slots {
+				key := PercolateSlot{Name: name, Slot: slot}
+				groups[key] = append(groups[key], m.ID)
+			}
+		}
+	}
+	for _, ids := range groups {
+		sort.Strings(ids)
+	}
+	return groups
+}
@@ -0,0 +1,328 @@
+package esquery
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// 管道聚合的空桶处理方式
+var (
+	GapSkip        = "skip"         // 跳过空桶(默认)
+	GapInsertZeros = "insert_zeros" // 空桶的值按0计算
+	GapKeepValues  = "keep_values"  // 保留非空值, 与skip类似但不跳过有值的桶
+)
+
+// parentPipelineAggs 作用于父级多桶聚合中每个桶的管道聚合, buckets_path相对于父级聚合
+var parentPipelineAggs = []string{
+	"derivative", "cumulative_sum", "cumulative_cardinality", "moving_fn", "serial_diff",
+	"bucket_script", "bucket_selector", "bucket_sort",
+}
+
+// histogramPipelineAggs 父级聚合需为histogram类的管道聚合
+var histogramPipelineAggs = []string{"derivative", "cumulative_sum", "cumulative_cardinality", "moving_fn", "serial_diff"}
+
+// histogramAggs histogram类的聚合
+var histogramAggs = []string{"histogram", "date_histogram", "auto_date_histogram", "variable_width_histogram"}
+
+// pipelineAgg 构造管道聚合
+func pipelineAgg(name, aggType string, bucketsPath any, opts ...Option) AggsMap {
+	paramMap := NewOptMap(opts...)
+	if bucketsPath != nil {
+		paramMap["buckets_path"] = bucketsPath
+	}
+	return AggsMap{Aggs: Map{name: Map{aggType: paramMap}}, key: name}
+}
+
+// DerivativeAgg 构造 Derivative 管道聚合, 计算相邻桶指标的差值, 需放在histogram或date_histogram中
+// @param name 聚合名称
+// @param bucketsPath 指标路径, 如 "price_sum"、"_count"
+// @param opts option不定参数, 如WithGapPolicy、WithUnit、WithFormat
+func DerivativeAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "derivative", bucketsPath, opts...)
+}
+
+// CumulativeSumAgg 构造 Cumulative Sum 管道聚合, 计算指标的累计值, 需放在histogram或date_histogram中
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数, 如WithFormat
+func CumulativeSumAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "cumulative_sum", bucketsPath, opts...)
+}
+
+// CumulativeCardinalityAgg 构造 Cumulative Cardinality 管道聚合, 计算累计去重数, 如累计新增用户
+// @param name 聚合名称
+// @param bucketsPath cardinality聚合的路径
+// @param opts option不定参数, 如WithFormat
+func CumulativeCardinalityAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "cumulative_cardinality", bucketsPath, opts...)
+}
+
+// MovingFnAgg 构造 Moving Function 管道聚合, 在滑动窗口上执行脚本, 如移动平均
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param window 窗口大小
+// @param script 脚本, 如 "MovingFunctions.unweightedAvg(values)"
+// @param opts option不定参数, 如WithShift、WithGapPolicy
+func MovingFnAgg(name, bucketsPath string, window int, script string, opts ...Option) AggsMap {
+	a := pipelineAgg(name, "moving_fn", bucketsPath, opts...)
+	params := a.Aggs[name].(Map)["moving_fn"].(Map)
+	params["window"] = window
+	params["script"] = script
+	return a
+}
+
+// SerialDiffAgg 构造 Serial Differencing 管道聚合, 计算与lag个桶之前的差值, 如同比
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数, 如WithLag、WithGapPolicy
+func SerialDiffAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "serial_diff", bucketsPath, opts...)
+}
+
+// BucketScriptAgg 构造 Bucket Script 管道聚合, 用脚本计算每个桶的新指标, 如转化率
+// @param name 聚合名称
+// @param bucketsPath 脚本变量名到指标路径的映射
+// @param script 脚本, 如 "params.paid / params.total"
+// @param opts option不定参数, 如WithGapPolicy、WithFormat
+func BucketScriptAgg(name string, bucketsPath map[string]string, script string, opts ...Option) AggsMap {
+	a := pipelineAgg(name, "bucket_script", bucketsPath, opts...)
+	a.Aggs[name].(Map)["bucket_script"].(Map)["script"] = script
+	return a
+}
+
+// BucketSelectorAgg 构造 Bucket Selector 管道聚合, 保留脚本返回true的桶
+// @param name 聚合名称
+// @param bucketsPath 脚本变量名到指标路径的映射
+// @param script 脚本, 如 "params.total > 100"
+// @param opts option不定参数, 如WithGapPolicy
+func BucketSelectorAgg(name string, bucketsPath map[string]string, script string, opts ...Option) AggsMap {
+	a := pipelineAgg(name, "bucket_selector", bucketsPath, opts...)
+	a.Aggs[name].(Map)["bucket_selector"].(Map)["script"] = script
+	return a
+}
+
+// BucketSortAgg 构造 Bucket Sort 聚合查询, 对聚合结果桶进行排序, 聚合名称为 field_bucket_sort
+// bucket_sort不作用于字段, field只用于生成名称, 指定名称使用BucketSortPipelineAgg
+func BucketSortAgg(field string, opts ...Option) AggsMap {
+	return BucketSortPipelineAgg(field+"_bucket_sort", opts...)
+}
+
+// BucketSortPipelineAgg 构造 Bucket Sort 管道聚合, 对父级聚合的桶排序和截断
+// @param name 聚合名称
+// @param opts option不定参数, 如WithSort、WithSize、WithFrom、WithGapPolicy
+func BucketSortPipelineAgg(name string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "bucket_sort", nil, opts...)
+}
+
+// AvgBucketAgg 构造 Avg Bucket 管道聚合, 计算兄弟多桶聚合中指标的平均值
+// @param name 聚合名称
+// @param bucketsPath 指标路径, 如 "sales_per_month>sales"
+// @param opts option不定参数, 如WithGapPolicy、WithFormat
+func AvgBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "avg_bucket", bucketsPath, opts...)
+}
+
+// MaxBucketAgg 构造 Max Bucket 管道聚合, 返回兄弟多桶聚合中指标最大的桶
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数
+func MaxBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "max_bucket", bucketsPath, opts...)
+}
+
+// MinBucketAgg 构造 Min Bucket 管道聚合, 返回兄弟多桶聚合中指标最小的桶
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数
+func MinBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "min_bucket", bucketsPath, opts...)
+}
+
+// SumBucketAgg 构造 Sum Bucket 管道聚合, 计算兄弟多桶聚合中指标的总和
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数
+func SumBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "sum_bucket", bucketsPath, opts...)
+}
+
+// StatsBucketAgg 构造 Stats Bucket 管道聚合, 计算兄弟多桶聚合中指标的统计信息
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数
+func StatsBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "stats_bucket", bucketsPath, opts...)
+}
+
+// ExtendedStatsBucketAgg 构造 Extended Stats Bucket 管道聚合, 计算兄弟多桶聚合中指标的扩展统计信息
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数, 如WithSigma
+func ExtendedStatsBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "extended_stats_bucket", bucketsPath, opts...)
+}
+
+// PercentilesBucketAgg 构造 Percentiles Bucket 管道聚合, 计算兄弟多桶聚合中指标的百分位数
+// @param name 聚合名称
+// @param bucketsPath 指标路径
+// @param opts option不定参数, 如WithPercents
+func PercentilesBucketAgg(name, bucketsPath string, opts ...Option) AggsMap {
+	return pipelineAgg(name, "percentiles_bucket", bucketsPath, opts...)
+}
+
+// WithGapPolicy 空桶的处理方式GapSkip/GapInsertZeros/GapKeepValues(管道聚合)
+func WithGapPolicy(value string) Option {
+	return func(m Map) {
+		m["gap_policy"] = value
+	}
+}
+
+// WithUnit 导数的时间单位, 如 "1s"、"1d"(derivative)
+func WithUnit(value string) Option {
+	return func(m Map) {
+		m["unit"] = value
+	}
+}
+
+// WithLag 与之相减的桶的间隔个数(serial_diff)
+// @param value 间隔, 如按月分桶时12为同比
+func WithLag(value int) Option {
+	return func(m Map) {
+		m["lag"] = value
+	}
+}
+
+// WithShift 窗口的偏移量, 为1时窗口包含当前桶(moving_fn)
+func WithShift(value int) Option {
+	return func(m Map) {
+		m["shift"] = value
+	}
+}
+
+// WithSigma 标准差边界的倍数(extended_stats_bucket)
+func WithSigma(value float64) Option {
+	return func(m Map) {
+		m["sigma"] = value
+	}
+}
+
+// WithPercents 需计算的百分位(percentiles、percentiles_bucket)
+func WithPercents(values ...float64) Option {
+	return func(m Map) {
+		m["percents"] = values
+	}
+}
+
+// Validate 校验组合的聚合, 如名称重复、子聚合不合法、buckets_path引用了不存在的聚合
+// QueryList、QueryAgg等查询函数发送请求前会自动校验, 单独构造DSL时可手动调用
+func (a AggsMap) Validate() error {
+	if a.err != nil {
+		return a.err
+	}
+	return validateNodes(a.Nodes(), nil)
+}
+
+// bucketsPaths 返回管道聚合的buckets_path, 按变量名排序
+func (n AggNode) bucketsPaths() []string {
+	switch v := n.params["buckets_path"].(type) {
+	case string:
+		return []string{v}
+	case map[string]string:
+		paths := make([]string, 0, len(v))
+		for _, p := range v {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		return paths
+	case Map:
+		paths := make([]string, 0, len(v))
+		for _, p := range v {
+			if s, ok := p.(string); ok {
+				paths = append(paths, s)
+			}
+		}
+		sort.Strings(paths)
+		return paths
+	}
+	return nil
+}
+
+// validatePipeline 校验管道聚合的位置和buckets_path
+// @param ancestors 祖先节点
+// @param siblings 同级节点, buckets_path从同级节点开始解析
+func (n AggNode) validatePipeline(ancestors, siblings []AggNode) error {
+	if slices.Contains(parentPipelineAggs, n.aggType) {
+		if len(ancestors) == 0 {
+			return fmt.Errorf("%s aggregation %s must be inside a multi-bucket aggregation", n.aggType, n.name)
+		}
+		parent := ancestors[len(ancestors)-1]
+		if slices.Contains(singleBucketAggs, parent.aggType) {
+			return fmt.Errorf("%s aggregation %s cannot be under single-bucket %s aggregation %s",
+				n.aggType, n.name, parent.aggType, parent.name)
+		}
+		if slices.Contains(histogramPipelineAggs, n.aggType) && !slices.Contains(histogramAggs, parent.aggType) {
+			return fmt.Errorf("%s aggregation %s must be inside a histogram or date_histogram, got %s",
+				n.aggType, n.name, parent.aggType)
+		}
+	}
+
+	paths := n.bucketsPaths()
+	if len(paths) == 0 && n.aggType != "bucket_sort" {
+		return fmt.Errorf("%s aggregation %s requires buckets_path", n.aggType, n.name)
+	}
+	for _, path := range paths {
+		if err := resolveBucketsPath(path, siblings, n.name); err != nil {
+			return fmt.Errorf("%s aggregation %s: %w", n.aggType, n.name, err)
+		}
+	}
+	return nil
+}
+
+// resolveBucketsPath 按 AGG>AGG.METRIC 的语法在聚合树中解析buckets_path
+func resolveBucketsPath(path string, siblings []AggNode, self string) error {
+	if path == "_count" || path == "_key" {
+		return nil
+	}
+
+	level := siblings
+	elems := strings.Split(path, ">")
+	for i, elem := range elems {
+		last := i == len(elems)-1
+		if last && (elem == "_count" || elem == "_bucket_count" || elem == "_key") {
+			return nil
+		}
+
+		name := elem
+		metric := ""
+		if j := strings.IndexByte(name, '['); j >= 0 {
+			name, metric = name[:j], name[j:]
+		} else if j := strings.LastIndexByte(name, '.'); last && j >= 0 && !hasAgg(level, name) {
+			// 同ES从最后一个.拆分指标名, 完整名称即为聚合名称时(如 items.price_sum)不拆分
+			name, metric = name[:j], name[j+1:]
+		}
+		if name == self {
+			return fmt.Errorf("buckets_path %s refers to itself", path)
+		}
+
+		idx := slices.IndexFunc(level, func(a AggNode) bool { return a.name == name })
+		if idx < 0 {
+			return fmt.Errorf("buckets_path %s refers to unknown aggregation %s", path, name)
+		}
+		node := level[idx]
+		if !last {
+			level = node.subs
+			continue
+		}
+		if metric != "" && metric != "value" && slices.Contains([]string{"avg", "sum", "min", "max", "value_count", "cardinality"}, node.aggType) {
+			return fmt.Errorf("buckets_path %s selects %s of single-value %s aggregation %s", path, metric, node.aggType, name)
+		}
+	}
+	return nil
+}
+
+// hasAgg 同级节点中是否有指定名称的聚合
+func hasAgg(nodes []AggNode, name string) bool {
+	return slices.ContainsFunc(nodes, func(a AggNode) bool { return a.name == name })
+}
@@ -12,6 +12,8 @@ type Query struct {
 	Index   string   `json:"index,omitempty"`   // 查询的索引名
 	DSL     *ESQuery `json:"dsl,omitempty"`     // 查询语句DSL
 	Comment string   `json:"comment,omitempty"` // 查询注释
+	Page    int      `json:"page,omitempty"`    // 当前页码, 从0开始
+	Cursors [][]any  `json:"cursors,omitempty"` // 已访问各页最后一条记录的排序值, 用于search_after
 }
 
 // Data 查询的结果数据
@@ -24,16 +26,27 @@ type Data struct {
 
 // ESQuery 定义主查询结构
 type ESQuery struct {
-	Query Map   `json:"query"`          // 查询条件
-	Sort  []Map `json:"sort,omitempty"` // 排序条件
-	Aggs  Map   `json:"aggs,omitempty"` // 聚合条件
-	Size  int   `json:"size,omitempty"` // 记录数
-}
-
-// JSON json序列化
-func (eq *ESQuery) JSON() string {
-	d, _ := json.Marshal(eq)
-	return string(d)
+	RuntimeMappings Map   `json:"runtime_mappings,omitempty"` // 运行时字段, 可在查询、排序、聚合中按普通字段使用
+	Query           Map   `json:"query,omitempty"`            // 查询条件
+	PostFilter      Map   `json:"post_filter,omitempty"`      // 聚合之后再过滤命中结果, 不影响聚合
+	Sort            []Map `json:"sort,omitempty"`             // 排序条件
+	Aggs            Map   `json:"aggs,omitempty"`             // 聚合条件
+	From            int   `json:"from,omitempty"`             // 起始偏移量
+	Size            int   `json:"size,omitempty"`             // 记录数
+	SearchAfter     []any `json:"search_after,omitempty"`     // 从指定排序值之后开始返回, 用于深度分页
+	Suggest         Map   `json:"suggest,omitempty"`          // 搜索建议
+	ScriptFields    Map   `json:"script_fields,omitempty"`    // 脚本计算的返回字段
+	Fields          []any `json:"fields,omitempty"`           // 额外返回的字段, 如运行时字段
+	Source          any   `json:"_source,omitempty"`          // 是否返回_source或返回的字段列表
+}
+
+// JSON json序列化, 查询参数不合法(如用户输入不符合WithInputPolicy的策略)时返回错误
+func (eq *ESQuery) JSON() (string, error) {
+	d, err := json.Marshal(eq)
+	if err != nil {
+		return "", err
+	}
+	return string(d), nil
 }
 
 // Bool 构造Bool查询（支持 must、should、filter、must_not等）
@@ -52,9 +65,9 @@ func Term(field string, value any, opts ...Option) Map {
 	return Map{"term": paramMap}
 }
 
-// Terms 构造 Term 查询, 进行精确匹配
+// Terms 构造 Terms 查询, 进行多值精确匹配
 // @param field 查询字段
-// @param values 查询值不定参数列表, 不进行分词
+// @param values 查询值列表, 不进行分词, []string、[]int等类型的切片使用TermsOf
 // @param opts option不定参数
 func Terms(field string, values []any, opts ...Option) Map {
 	paramMap := NewOptMap(opts...)
@@ -62,6 +75,18 @@ func Terms(field string, values []any, opts ...Option) Map {
 	return Map{"terms": paramMap}
 }
 
+// TermsOf 构造 Terms 查询, Terms的泛型版本, 查询值可为任意元素类型的切片
+// @param field 查询字段
+// @param values 查询值列表, 不进行分词
+// @param opts option不定参数
+func TermsOf[V any](field string, values []V, opts ...Option) Map {
+	vs := make([]any, 0, len(values))
+	for _, v := range values {
+		vs = append(vs, v)
+	}
+	return Terms(field, vs, opts...)
+}
+
 // Match 构造 Match 查询, 全文检索(模糊匹配、分词搜索、相关度评分)
 // @param field 查询字段
 // @param value 查询值, 进行分词
@@ -83,7 +108,7 @@ func MultiMatch(query string, fields []string, opts ...Option) Map {
 	return Map{"multi_match": paramMap}
 }
 
-// Range 构造 Range 查询
+// Range 构造 Range 查询, 需类型检查和边界校验时使用NumberRange、StringRange、TimeRange
 // @param field 查询字段
 // @param gte (>=)大于等于指定值
 // @param gt (>)大于指定值
@@ -139,13 +164,13 @@ func ScriptScore(query Map, script string, opts ...Option) Map {
 	}
 }
 
-// Wildcard 构造Wildcard查询, 基于通配符的字符串匹配
+// Wildcard 构造Wildcard查询, 基于通配符的字符串匹配, 用户输入应使用EscapeWildcard转义、WithInputPolicy或改用SafeWildcard
 // @param field 查询字段
 // @param value 通配符表达式
-// @param opts option不定参数, 为script指定参数
+// @param opts option不定参数, 如WithInputPolicy
 func Wildcard(field string, value string, opts ...Option) Map {
 	paramMap := NewOptMap(opts...)
-	paramMap["value"] = value
+	paramMap["value"], _, _ = policyValue(paramMap, wildcardRule, value)
 	return Map{
 		"wildcard": Map{
 			field: paramMap,
@@ -172,18 +197,6 @@ func Exists(field string) Map {
 func GeoDistance(field string, lat, lon float64, distance string, opts ...Option) Map {
 	paramMap := NewOptMap(opts...)
 	paramMap["distance"] = distance
-	paramMap[field] = map[string]float64{"lat": lat, "lon": lon}
+	paramMap[field] = GeoPoint{Lat: lat, Lon: lon}.latLon()
 	return Map{"geo_distance": paramMap}
 }
-
-// Knn 构造 KNN 查询
-// @param field 查询字段
-// @param vector 查询向量
-// @param filter 过滤条件
-// @param opts option不定参数
-func Knn(field string, vector []float32, opts ...Option) Map {
-	paramMap := NewOptMap(opts...)
-	paramMap["field"] = field
-	paramMap["query_vector"] = vector
-	return Map{"knn": paramMap}
-}
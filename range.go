@@ -0,0 +1,231 @@
+package esquery
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Number 数值类型
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// rangeRelations range类型字段支持的匹配关系
+var rangeRelations = []string{Intersects, Contains, Within}
+
+// RangeBuilder 类型化的范围查询构造器, 方法返回新的构造器, 生成DSL时校验边界
+type RangeBuilder[V cmp.Ordered] struct {
+	q     RangeQuery
+	lower *V // 下界, 用于校验上下界
+	upper *V // 上界
+	err   error
+}
+
+// NumberRange 构造数值字段的范围查询, 如 NumberRange[int]("age").Gte(18).Lt(60)
+// @param field 查询字段
+func NumberRange[N Number](field string) RangeBuilder[N] {
+	return RangeBuilder[N]{q: RangeQuery{Field: field}}
+}
+
+// StringRange 构造keyword字段的范围查询, 按字典序比较
+// @param field 查询字段
+func StringRange(field string) RangeBuilder[string] {
+	return RangeBuilder[string]{q: RangeQuery{Field: field}}
+}
+
+// Gte (>=)大于等于
+func (b RangeBuilder[V]) Gte(v V) RangeBuilder[V] { return b.bound("gte", v) }
+
+// Gt (>)大于
+func (b RangeBuilder[V]) Gt(v V) RangeBuilder[V] { return b.bound("gt", v) }
+
+// Lt (<)小于
+func (b RangeBuilder[V]) Lt(v V) RangeBuilder[V] { return b.bound("lt", v) }
+
+// Lte (<=)小于等于
+func (b RangeBuilder[V]) Lte(v V) RangeBuilder[V] { return b.bound("lte", v) }
+
+// Relation range类型字段的匹配关系Intersects/Contains/Within
+func (b RangeBuilder[V]) Relation(relation string) RangeBuilder[V] {
+	b.q.Relation = relation
+	return b
+}
+
+// Boost 权重
+func (b RangeBuilder[V]) Boost(boost float64) RangeBuilder[V] {
+	b.q.Boost = boost
+	return b
+}
+
+// bound 设置边界, 同一方向的边界只能设置一次
+func (b RangeBuilder[V]) bound(key string, v V) RangeBuilder[V] {
+	if b.err != nil {
+		return b
+	}
+	b.err = setRangeBound(&b.q, key, v)
+	if key == "gte" || key == "gt" {
+		b.lower = &v
+	} else {
+		b.upper = &v
+	}
+	return b
+}
+
+// Source 生成查询DSL
+func (b RangeBuilder[V]) Source() (any, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.lower != nil && b.upper != nil && cmp.Compare(*b.lower, *b.upper) > 0 {
+		return nil, fmt.Errorf("range query on %s has lower bound %v greater than upper bound %v",
+			b.q.Field, *b.lower, *b.upper)
+	}
+	return rangeSource(b.q)
+}
+
+// Map 查询的DSL, 用于放入[]Map形式的Bool子句, 边界不合法时返回错误
+func (b RangeBuilder[V]) Map() (Map, error) {
+	return ToMap(b)
+}
+
+// MarshalJSON json序列化
+func (b RangeBuilder[V]) MarshalJSON() ([]byte, error) { return marshalQuery(b) }
+
+// DateRangeBuilder 类型化的日期范围查询构造器, 边界可为time.Time或日期表达式, 如NDayAgo(7)
+type DateRangeBuilder struct {
+	q     RangeQuery
+	lower time.Time // 下界, 用于校验上下界
+	upper time.Time // 上界
+	err   error
+}
+
+// TimeRange 构造日期字段的范围查询, 如 TimeRange("ctime").GteExpr(NDayAgo(7)).Lt(end)
+// @param field 查询字段
+func TimeRange(field string) DateRangeBuilder {
+	return DateRangeBuilder{q: RangeQuery{Field: field}}
+}
+
+// Gte (>=)大于等于, time.Time按RFC3339序列化
+func (b DateRangeBuilder) Gte(t time.Time) DateRangeBuilder { return b.bound("gte", t) }
+
+// Gt (>)大于
+func (b DateRangeBuilder) Gt(t time.Time) DateRangeBuilder { return b.bound("gt", t) }
+
+// Lt (<)小于
+func (b DateRangeBuilder) Lt(t time.Time) DateRangeBuilder { return b.bound("lt", t) }
+
+// Lte (<=)小于等于
+func (b DateRangeBuilder) Lte(t time.Time) DateRangeBuilder { return b.bound("lte", t) }
+
+// GteExpr (>=)大于等于日期表达式, 如Today()、"now-1d/d"
+func (b DateRangeBuilder) GteExpr(expr string) DateRangeBuilder { return b.bound("gte", expr) }
+
+// GtExpr (>)大于日期表达式
+func (b DateRangeBuilder) GtExpr(expr string) DateRangeBuilder { return b.bound("gt", expr) }
+
+// LtExpr (<)小于日期表达式
+func (b DateRangeBuilder) LtExpr(expr string) DateRangeBuilder { return b.bound("lt", expr) }
+
+// LteExpr (<=)小于等于日期表达式
+func (b DateRangeBuilder) LteExpr(expr string) DateRangeBuilder { return b.bound("lte", expr) }
+
+// Format 字符串边界的日期格式, 同时传time.Time时需兼容RFC3339, 如 "yyyy-MM-dd||strict_date_optional_time"
+func (b DateRangeBuilder) Format(format string) DateRangeBuilder {
+	b.q.Format = format
+	return b
+}
+
+// TimeZone 字符串边界和日期表达式的时区, 如 "+08:00"、"Asia/Shanghai"
+func (b DateRangeBuilder) TimeZone(timeZone string) DateRangeBuilder {
+	b.q.TimeZone = timeZone
+	return b
+}
+
+// Relation date_range类型字段的匹配关系Intersects/Contains/Within
+func (b DateRangeBuilder) Relation(relation string) DateRangeBuilder {
+	b.q.Relation = relation
+	return b
+}
+
+// Boost 权重
+func (b DateRangeBuilder) Boost(boost float64) DateRangeBuilder {
+	b.q.Boost = boost
+	return b
+}
+
+// bound 设置边界, 同一方向的边界只能设置一次
+func (b DateRangeBuilder) bound(key string, v any) DateRangeBuilder {
+	if b.err != nil {
+		return b
+	}
+	if s, ok := v.(string); ok && s == "" {
+		b.err = fmt.Errorf("range query on %s has empty %s", b.q.Field, key)
+		return b
+	}
+	b.err = setRangeBound(&b.q, key, v)
+	if t, ok := v.(time.Time); ok {
+		if key == "gte" || key == "gt" {
+			b.lower = t
+		} else {
+			b.upper = t
+		}
+	}
+	return b
+}
+
+// Source 生成查询DSL
+func (b DateRangeBuilder) Source() (any, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if !b.lower.IsZero() && !b.upper.IsZero() && b.lower.After(b.upper) {
+		return nil, fmt.Errorf("range query on %s has lower bound %s after upper bound %s",
+			b.q.Field, b.lower.Format(time.RFC3339), b.upper.Format(time.RFC3339))
+	}
+	return rangeSource(b.q)
+}
+
+// Map 查询的DSL, 用于放入[]Map形式的Bool子句, 边界不合法时返回错误
+func (b DateRangeBuilder) Map() (Map, error) {
+	return ToMap(b)
+}
+
+// MarshalJSON json序列化
+func (b DateRangeBuilder) MarshalJSON() ([]byte, error) { return marshalQuery(b) }
+
+// setRangeBound 设置范围查询的边界, 与已有边界冲突时返回错误
+func setRangeBound(q *RangeQuery, key string, v any) error {
+	slots := map[string]*any{"gte": &q.Gte, "gt": &q.Gt, "lt": &q.Lt, "lte": &q.Lte}
+	opposite := map[string]string{"gte": "gt", "gt": "gte", "lt": "lte", "lte": "lt"}
+	if *slots[key] != nil {
+		return fmt.Errorf("range query on %s sets %s twice", q.Field, key)
+	}
+	if *slots[opposite[key]] != nil {
+		return fmt.Errorf("range query on %s sets both %s and %s", q.Field, opposite[key], key)
+	}
+	*slots[key] = v
+	return nil
+}
+
+// rangeSource 校验匹配关系并生成范围查询的DSL
+func rangeSource(q RangeQuery) (any, error) {
+	if q.Relation != "" && !containsFold(rangeRelations, q.Relation) {
+		return nil, fmt.Errorf("range query on %s does not support relation %s", q.Field, q.Relation)
+	}
+	if q.Gte == nil && q.Gt == nil && q.Lt == nil && q.Lte == nil {
+		return nil, fmt.Errorf("range query on %s requires at least one bound", q.Field)
+	}
+	return q.Source()
+}
+
+// containsFold 忽略大小写判断是否包含
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package esquery
+
+// DistanceFeature 构造 DistanceFeature 查询, 距原点越近得分越高, 用于时间新鲜度或地理邻近度加分
+// 一般放在Bool的should中, 对满足条件的文档提升排名
+// @param field date、date_nanos或geo_point字段
+// @param origin 原点, 日期(如 "now")或地理坐标(如GeoPoint)
+// @param pivot 距原点pivot处得分为boost的一半, 如 "7d"、"1km"
+// @param opts option不定参数, 如WithBoost
+func DistanceFeature(field string, origin any, pivot string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["field"] = field
+	if p, ok := origin.(GeoPoint); ok {
+		origin = p.latLon()
+	}
+	paramMap["origin"] = origin
+	paramMap["pivot"] = pivot
+	return Map{"distance_feature": paramMap}
+}
+
+// RankFeature 构造 RankFeature 查询, 按rank_feature或rank_features字段的值提升得分, 如PageRank、点击量
+// @param field rank_feature字段
+// @param opts option不定参数, WithSaturation、WithLog、WithSigmoid、WithLinear之一, 以及WithBoost
+func RankFeature(field string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["field"] = field
+	return Map{"rank_feature": paramMap}
+}
+
+// WithSaturation 饱和函数 S/(S+pivot), 得分趋近于1(rank_feature, 默认)
+// @param pivot 得分为0.5时的特征值, 为0时由ES按特征值的几何平均数估算
+func WithSaturation(pivot float64) Option {
+	return func(m Map) {
+		params := Map{}
+		if pivot > 0 {
+			params["pivot"] = pivot
+		}
+		m["saturation"] = params
+	}
+}
+
+// WithLog 对数函数 log(scaling_factor+S)(rank_feature)
+// @param scalingFactor 缩放因子, 需大于等于1
+func WithLog(scalingFactor float64) Option {
+	return func(m Map) {
+		m["log"] = Map{"scaling_factor": scalingFactor}
+	}
+}
+
+// WithSigmoid S型函数 S^exp/(S^exp+pivot^exp)(rank_feature)
+// @param pivot 得分为0.5时的特征值
+// @param exponent 指数, 一般在0.5到1之间
+func WithSigmoid(pivot, exponent float64) Option {
+	return func(m Map) {
+		m["sigmoid"] = Map{"pivot": pivot, "exponent": exponent}
+	}
+}
+
+// WithLinear 线性函数 S, 得分与特征值成正比(rank_feature)
+func WithLinear() Option {
+	return func(m Map) {
+		m["linear"] = Map{}
+	}
+}
+
+// Pinned 构造 Pinned 查询, 指定的文档按顺序置顶, 其后为organic查询的自然结果
+// @param ids 置顶的文档ID, 按排列顺序
+// @param organic 自然结果的查询
+func Pinned(ids []string, organic Map) Map {
+	return Map{
+		"pinned": Map{
+			"ids":     ids,
+			"organic": organic,
+		},
+	}
+}
+
+// PinnedDocs 构造 Pinned 查询, 置顶的文档可来自不同索引
+// @param docs 置顶的文档, 按排列顺序
+// @param organic 自然结果的查询
+func PinnedDocs(docs []DocRef, organic Map) Map {
+	refs := make([]Map, 0, len(docs))
+	for _, d := range docs {
+		refs = append(refs, Map{"_index": d.Index, "_id": d.ID})
+	}
+	return Map{
+		"pinned": Map{
+			"docs":    refs,
+			"organic": organic,
+		},
+	}
+}
+
+// ScriptQuery 构造 Script 查询, 按脚本返回的布尔值过滤文档, 一般放在Bool的filter中
+// @param source 返回布尔值的脚本, 如 "doc['price'].value * doc['qty'].value > params.min"
+// @param opts option不定参数, 为script指定参数
+func ScriptQuery(source string, opts ...Option) Map {
+	return Map{
+		"script": Map{
+			"script": newScript(source, opts...),
+		},
+	}
+}
@@ -2,6 +2,8 @@ package esquery
 
 import (
 	"encoding/json"
+	"slices"
+	"sort"
 	"strings"
 )
 
@@ -11,12 +13,95 @@ type Result[T any] struct {
 		Total struct {
 			Value int `json:"value"`
 		} `json:"total"`
-		Hits []struct {
-			ID     string `json:"_id"`
-			Source *T     `json:"_source"`
-		} `json:"hits"`
+		MaxScore float64  `json:"max_score"`
+		Hits     []Hit[T] `json:"hits"`
 	} `json:"hits"`
 	Aggregations map[string]json.RawMessage `json:"aggregations"` // json.RawMessage 使用各AggResult解析
+	Suggest      map[string]json.RawMessage `json:"suggest"`      // json.RawMessage 使用ParseSuggest解析
+}
+
+// Hit 单条命中的文档
+type Hit[T any] struct {
+	Index  string  `json:"_index"`         // 所属索引
+	ID     string  `json:"_id"`            // 文档ID
+	Score  float64 `json:"_score"`         // 相关度评分, 按字段排序时为0
+	Source *T      `json:"_source"`        // 文档内容
+	Sort   []any   `json:"sort,omitempty"` // 排序值
+
+	Fields    map[string]json.RawMessage `json:"fields,omitempty"`     // 脚本字段、运行时字段等, 使用FieldValues解析
+	InnerHits map[string]json.RawMessage `json:"inner_hits,omitempty"` // 嵌套或父子文档的inner_hits, 使用InnerHitsOf解析
+	Nested    *NestedIdentity            `json:"_nested,omitempty"`    // 嵌套文档在父文档中的位置, 仅inner_hits中存在
+}
+
+// NestedIdentity 嵌套文档的位置
+type NestedIdentity struct {
+	Field  string          `json:"field"`             // 嵌套字段
+	Offset int             `json:"offset"`            // 在数组中的下标
+	Nested *NestedIdentity `json:"_nested,omitempty"` // 多级嵌套时的下一级位置
+}
+
+// aggTypes 默认聚合名称 field_type 中可识别的聚合类型
+var aggTypes = []string{
+	"terms", "range", "avg", "sum", "max", "min", "value_count", "cardinality", "stats", "extended_stats",
+	"percentiles", "percentile_ranks", "histogram", "date_histogram", "geo_distance", "geohash_grid",
+	"filter", "filters", "nested", "adjacency_matrix", "top_hits", "terms_set", "bucket_sort", "scripted_metric", "composite",
+	"date_range", "ip_range", "multi_terms", "rare_terms", "auto_date_histogram", "variable_width_histogram", "global",
+	"missing", "sampler", "diversified_sampler", "reverse_nested", "children", "parent", "geotile_grid", "geohex_grid",
+}
+
+// aggTypeOf 按默认名称 field_type 解析聚合类型, 取最长的匹配, 如 ctime_date_histogram 为date_histogram而非histogram
+func aggTypeOf(name string) string {
+	aggType := ""
+	for _, t := range aggTypes {
+		if strings.HasSuffix(name, "_"+t) && len(t) > len(aggType) {
+			aggType = t
+		}
+	}
+	return aggType
+}
+
+// rawAgg 按默认名称 field_type 提取指定类型的聚合结果, 多个时按名称排序取第一个, 按名称提取使用DecodeAgg
+func rawAgg(agg map[string]json.RawMessage, aggType string) []byte {
+	names := make([]string, 0, len(agg))
+	for k := range agg {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if aggTypeOf(k) == aggType {
+			return agg[k]
+		}
+	}
+	return nil
+}
+
+// subAggSkipKeys 聚合结果中值为对象但不是子聚合的字段
+var subAggSkipKeys = []string{"key", "meta", "after_key", "buckets"}
+
+// decodeSubAggs 提取聚合结果或桶中的子聚合, 即值为对象的字段
+func decodeSubAggs(data []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	subs := map[string]json.RawMessage{}
+	for k, v := range fields {
+		if len(v) > 0 && v[0] == '{' && !slices.Contains(subAggSkipKeys, k) {
+			subs[k] = v
+		}
+	}
+	return subs, nil
+}
+
+// unmarshalWithAggs 反序列化聚合结果或桶, 同时提取子聚合
+// @param plain 不带UnmarshalJSON方法的目标类型指针, 避免递归
+func unmarshalWithAggs(data []byte, plain any, aggs *map[string]json.RawMessage) error {
+	if err := json.Unmarshal(data, plain); err != nil {
+		return err
+	}
+	var err error
+	*aggs, err = decodeSubAggs(data)
+	return err
 }
 
 // TermsAggBucket 表示 terms 聚合中的一个桶（Bucket）
@@ -33,12 +118,7 @@ type TermsAggResult struct {
 
 // Raw 提取terms对应的json序列
 func (t TermsAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_terms") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "terms")
 }
 
 // RangeAggBucket 表示 range 聚合中的一个范围桶
@@ -56,12 +136,7 @@ type RangeAggResult struct {
 
 // Raw 提取range对应的json序列
 func (t RangeAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_range") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "range")
 }
 
 // AvgAggResult 表示avg聚合结果
@@ -71,12 +146,7 @@ type AvgAggResult struct {
 
 // Raw 提取avg对应的json序列
 func (r AvgAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_avg") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "avg")
 }
 
 // SumAggResult 表示sum聚合结果
@@ -86,12 +156,7 @@ type SumAggResult struct {
 
 // Raw 提取sum对应的json序列
 func (r SumAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_sum") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "sum")
 }
 
 // MaxAggResult 表示max聚合结果
@@ -101,12 +166,7 @@ type MaxAggResult struct {
 
 // Raw 提取max对应的json序列
 func (r MaxAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_max") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "max")
 }
 
 // MinAggResult 表示min聚合结果
@@ -116,12 +176,7 @@ type MinAggResult struct {
 
 // Raw 提取min对应的json序列
 func (r MinAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_min") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "min")
 }
 
 // DateHistogramBucket 表示某个时间区间的统计
@@ -138,12 +193,7 @@ type DateHistogramAggResult struct {
 
 // Raw 提取date_histogram对应的json序列
 func (t DateHistogramAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_date_histogram") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "date_histogram")
 }
 
 // StatsAggResult 表示统计聚合结果（min/max/avg/sum/count）
@@ -157,12 +207,7 @@ type StatsAggResult struct {
 
 // Raw 提取stats对应的json序列
 func (t StatsAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_stats") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "stats")
 }
 
 // ExtendedStatsAggResult 提供更详细的统计信息
@@ -179,12 +224,7 @@ type ExtendedStatsAggResult struct {
 
 // Raw 提取extended_stats对应的json序列
 func (t ExtendedStatsAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_extended_stats") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "extended_stats")
 }
 
 // CardinalityAggResult 表示去重统计结果（唯一值数量）
@@ -194,12 +234,7 @@ type CardinalityAggResult struct {
 
 // Raw 提取cardinality对应的json序列
 func (t CardinalityAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_cardinality") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "cardinality")
 }
 
 // ValueCountAggResult 表示字段非空值的文档数
@@ -207,14 +242,9 @@ type ValueCountAggResult struct {
 	Value int `json:"value"` // 非空字段的文档数量
 }
 
-// Raw 提取value对应的json序列
+// Raw 提取value_count对应的json序列
 func (t ValueCountAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_value") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "value_count")
 }
 
 // PercentilesAggResult 表示percentiles聚合的结果（百分位数）
@@ -224,12 +254,7 @@ type PercentilesAggResult struct {
 
 // Raw 提取percentiles对应的json序列
 func (r PercentilesAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_percentiles") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "percentiles")
 }
 
 // PercentileRanksAggResult 表示 percentile_ranks 聚合结果
@@ -239,12 +264,7 @@ type PercentileRanksAggResult struct {
 
 // Raw 提取 percentile_ranks 聚合的 JSON 数据
 func (r PercentileRanksAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_percentile_ranks") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "percentile_ranks")
 }
 
 // HistogramAggBucket 表示 histogram 聚合的桶
@@ -260,12 +280,7 @@ type HistogramAggResult struct {
 
 // Raw 提取 histogram 聚合的 JSON 数据
 func (r HistogramAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_histogram") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "histogram")
 }
 
 // GeoDistanceAggBucket 表示 geo_distance 聚合的距离桶
@@ -299,12 +314,7 @@ type GeoDistanceAggResult struct {
 
 // Raw 提取 geo_distance 聚合的 JSON 数据
 func (r GeoDistanceAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_geo_distance") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "geo_distance")
 }
 
 // GeohashGridAggBucket 表示 geohash_grid 聚合的地理网格桶
@@ -320,44 +330,232 @@ type GeohashGridAggResult struct {
 
 // Raw 提取 geohash_grid 聚合的 JSON 数据
 func (r GeohashGridAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_geohash_grid") {
-			return v
-		}
+	return rawAgg(agg, "geohash_grid")
+}
+
+// unmarshalSingleBucket 解析单桶聚合的结果, 同时提取子聚合
+func unmarshalSingleBucket(data []byte, docCount *int, aggs *map[string]json.RawMessage) error {
+	var b struct {
+		DocCount int `json:"doc_count"`
+	}
+	if err := unmarshalWithAggs(data, &b, aggs); err != nil {
+		return err
 	}
+	*docCount = b.DocCount
 	return nil
 }
 
 // FilterAggResult 表示 filter 聚合的结果
 type FilterAggResult struct {
-	DocCount int `json:"doc_count"` // 满足 filter 条件的文档数量
+	DocCount int                        `json:"doc_count"` // 满足过滤条件的文档数量
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *FilterAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
 }
 
 // Raw 提取 filter 聚合的 JSON 数据
 func (r FilterAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_filter") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "filter")
 }
 
-// NestedAggResult 表示 nested 聚合的结果（嵌套文档的 doc_count）
+// NestedAggResult 表示 nested 聚合的结果
 type NestedAggResult struct {
-	DocCount int `json:"doc_count"` // 嵌套文档数
+	DocCount int                        `json:"doc_count"` // 嵌套文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *NestedAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
 }
 
 // Raw 提取 nested 聚合的 JSON 数据
 func (r NestedAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_nested") {
-			return v
-		}
+	return rawAgg(agg, "nested")
+}
+
+// ReverseNestedAggResult 表示 reverse_nested 聚合的结果
+type ReverseNestedAggResult struct {
+	DocCount int                        `json:"doc_count"` // 父文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *ReverseNestedAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 reverse_nested 聚合的 JSON 数据
+func (r ReverseNestedAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "reverse_nested")
+}
+
+// GlobalAggResult 表示 global 聚合的结果
+type GlobalAggResult struct {
+	DocCount int                        `json:"doc_count"` // 索引中的全部文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *GlobalAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 global 聚合的 JSON 数据
+func (r GlobalAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "global")
+}
+
+// MissingAggResult 表示 missing 聚合的结果
+type MissingAggResult struct {
+	DocCount int                        `json:"doc_count"` // 缺失该字段的文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *MissingAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 missing 聚合的 JSON 数据
+func (r MissingAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "missing")
+}
+
+// SamplerAggResult 表示 sampler 聚合的结果
+type SamplerAggResult struct {
+	DocCount int                        `json:"doc_count"` // 采样的文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *SamplerAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 sampler 聚合的 JSON 数据
+func (r SamplerAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "sampler")
+}
+
+// DiversifiedSamplerAggResult 表示 diversified_sampler 聚合的结果
+type DiversifiedSamplerAggResult struct {
+	DocCount int                        `json:"doc_count"` // 采样的文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *DiversifiedSamplerAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 diversified_sampler 聚合的 JSON 数据
+func (r DiversifiedSamplerAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "diversified_sampler")
+}
+
+// ChildrenAggResult 表示 children 聚合的结果
+type ChildrenAggResult struct {
+	DocCount int                        `json:"doc_count"` // 子文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *ChildrenAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 children 聚合的 JSON 数据
+func (r ChildrenAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "children")
+}
+
+// ParentAggResult 表示 parent 聚合的结果
+type ParentAggResult struct {
+	DocCount int                        `json:"doc_count"` // 父文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (r *ParentAggResult) UnmarshalJSON(data []byte) error {
+	return unmarshalSingleBucket(data, &r.DocCount, &r.Aggs)
+}
+
+// Raw 提取 parent 聚合的 JSON 数据
+func (r ParentAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "parent")
+}
+
+// FiltersBucket 表示 filters 聚合中的一个桶
+type FiltersBucket struct {
+	Key      string                     `json:"key"`       // 过滤条件名称, 匿名filters时为空
+	DocCount int                        `json:"doc_count"` // 满足过滤条件的文档数量
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *FiltersBucket) UnmarshalJSON(data []byte) error {
+	type plain FiltersBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// FiltersAggResult 表示 filters 聚合的结果
+// 命名的filters按名称排序, 匿名的filters按过滤条件的顺序(other桶在最后)
+type FiltersAggResult struct {
+	Buckets []FiltersBucket `json:"buckets"`
+}
+
+// UnmarshalJSON json反序列化, 兼容命名(对象)和匿名(数组)两种桶格式
+func (r *FiltersAggResult) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Buckets json.RawMessage `json:"buckets"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Buckets) == 0 {
+		return nil
+	}
+	if raw.Buckets[0] == '[' {
+		return json.Unmarshal(raw.Buckets, &r.Buckets)
+	}
+
+	var named map[string]FiltersBucket
+	if err := json.Unmarshal(raw.Buckets, &named); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(named))
+	for k := range named {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	r.Buckets = make([]FiltersBucket, 0, len(keys))
+	for _, k := range keys {
+		b := named[k]
+		b.Key = k
+		r.Buckets = append(r.Buckets, b)
 	}
 	return nil
 }
 
+// Bucket 按名称获取桶
+func (r FiltersAggResult) Bucket(key string) (FiltersBucket, bool) {
+	for _, b := range r.Buckets {
+		if b.Key == key {
+			return b, true
+		}
+	}
+	return FiltersBucket{}, false
+}
+
+// Raw 提取 filters 聚合的 JSON 数据
+func (r FiltersAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "filters")
+}
+
 // AdjacencyMatrixAggBucket 表示 adjacency_matrix 中的每个桶
 type AdjacencyMatrixAggBucket struct {
 	Key      string `json:"key"`       // 桶的组合键（多个 filter 的交集）
@@ -371,10 +569,187 @@ type AdjacencyMatrixAggResult struct {
 
 // Raw 提取 adjacency_matrix 聚合的 JSON 数据
 func (r AdjacencyMatrixAggResult) Raw(agg map[string]json.RawMessage) []byte {
-	for k, v := range agg {
-		if strings.HasSuffix(k, "_adjacency_matrix") {
-			return v
-		}
-	}
-	return nil
+	return rawAgg(agg, "adjacency_matrix")
+}
+
+// DateRangeAggBucket 表示 date_range 聚合中的一个范围桶
+type DateRangeAggBucket struct {
+	Key          string                     `json:"key"`                      // 自定义 key, 未指定时由起止时间生成
+	From         *float64                   `json:"from,omitempty"`           // 起始毫秒时间戳（可为 nil）
+	FromAsString string                     `json:"from_as_string,omitempty"` // 可读的起始时间
+	To           *float64                   `json:"to,omitempty"`             // 结束毫秒时间戳（可为 nil）
+	ToAsString   string                     `json:"to_as_string,omitempty"`   // 可读的结束时间
+	DocCount     int                        `json:"doc_count"`                // 属于该范围的文档数量
+	Aggs         map[string]json.RawMessage `json:"-"`                        // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *DateRangeAggBucket) UnmarshalJSON(data []byte) error {
+	type plain DateRangeAggBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// DateRangeAggResult 表示 date_range 聚合的结果
+type DateRangeAggResult struct {
+	Buckets []DateRangeAggBucket `json:"buckets"`
+}
+
+// Raw 提取 date_range 聚合的 JSON 数据
+func (r DateRangeAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "date_range")
+}
+
+// IPRangeAggBucket 表示 ip_range 聚合中的一个范围桶
+type IPRangeAggBucket struct {
+	Key      string                     `json:"key"`            // 自定义 key 或 CIDR
+	From     string                     `json:"from,omitempty"` // 起始IP, 不一定存在
+	To       string                     `json:"to,omitempty"`   // 结束IP, 不一定存在
+	DocCount int                        `json:"doc_count"`      // 属于该范围的文档数量
+	Aggs     map[string]json.RawMessage `json:"-"`              // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *IPRangeAggBucket) UnmarshalJSON(data []byte) error {
+	type plain IPRangeAggBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// IPRangeAggResult 表示 ip_range 聚合的结果
+type IPRangeAggResult struct {
+	Buckets []IPRangeAggBucket `json:"buckets"`
+}
+
+// Raw 提取 ip_range 聚合的 JSON 数据
+func (r IPRangeAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "ip_range")
+}
+
+// MultiTermsAggBucket 表示 multi_terms 聚合中的一个桶, 即多个字段值的一个组合
+type MultiTermsAggBucket struct {
+	Key         []any                      `json:"key"`           // 各字段的值, 顺序同MultiTermsAgg的fields
+	KeyAsString string                     `json:"key_as_string"` // 以|连接的字段值
+	DocCount    int                        `json:"doc_count"`     // 匹配该组合的文档数
+	Aggs        map[string]json.RawMessage `json:"-"`             // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *MultiTermsAggBucket) UnmarshalJSON(data []byte) error {
+	type plain MultiTermsAggBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// MultiTermsAggResult 表示 multi_terms 聚合的结果
+type MultiTermsAggResult struct {
+	Buckets []MultiTermsAggBucket `json:"buckets"`
+}
+
+// Raw 提取 multi_terms 聚合的 JSON 数据
+func (r MultiTermsAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "multi_terms")
+}
+
+// RareTermsAggBucket 表示 rare_terms 聚合中的一个桶
+type RareTermsAggBucket struct {
+	Key      any                        `json:"key"`       // 词项, 数值字段时为数字
+	DocCount int                        `json:"doc_count"` // 匹配该词项的文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *RareTermsAggBucket) UnmarshalJSON(data []byte) error {
+	type plain RareTermsAggBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// RareTermsAggResult 表示 rare_terms 聚合的结果
+type RareTermsAggResult struct {
+	Buckets []RareTermsAggBucket `json:"buckets"`
+}
+
+// Raw 提取 rare_terms 聚合的 JSON 数据
+func (r RareTermsAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "rare_terms")
+}
+
+// AutoDateHistogramBucket 表示 auto_date_histogram 聚合中某个时间区间的统计
+type AutoDateHistogramBucket struct {
+	KeyAsString string                     `json:"key_as_string"` // 可读的时间字符串
+	Key         int64                      `json:"key"`           // 毫秒时间戳
+	DocCount    int                        `json:"doc_count"`     // 区间内文档数
+	Aggs        map[string]json.RawMessage `json:"-"`             // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *AutoDateHistogramBucket) UnmarshalJSON(data []byte) error {
+	type plain AutoDateHistogramBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// AutoDateHistogramAggResult 表示 auto_date_histogram 聚合的结果
+type AutoDateHistogramAggResult struct {
+	Buckets  []AutoDateHistogramBucket `json:"buckets"`  // 时间区间桶
+	Interval string                    `json:"interval"` // ES选择的间隔, 如 7d、1M
+}
+
+// Raw 提取 auto_date_histogram 聚合的 JSON 数据
+func (r AutoDateHistogramAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "auto_date_histogram")
+}
+
+// VariableWidthHistogramBucket 表示 variable_width_histogram 聚合中的一个区间
+type VariableWidthHistogramBucket struct {
+	Min      float64                    `json:"min"`       // 区间内的最小值
+	Key      float64                    `json:"key"`       // 区间的中心值
+	Max      float64                    `json:"max"`       // 区间内的最大值
+	DocCount int                        `json:"doc_count"` // 区间内文档数量
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *VariableWidthHistogramBucket) UnmarshalJSON(data []byte) error {
+	type plain VariableWidthHistogramBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// VariableWidthHistogramAggResult 表示 variable_width_histogram 聚合的结果
+type VariableWidthHistogramAggResult struct {
+	Buckets []VariableWidthHistogramBucket `json:"buckets"`
+}
+
+// Raw 提取 variable_width_histogram 聚合的 JSON 数据
+func (r VariableWidthHistogramAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "variable_width_histogram")
+}
+
+// GeoGridAggBucket 表示 geotile_grid、geohex_grid 聚合的网格桶
+type GeoGridAggBucket struct {
+	Key      string                     `json:"key"`       // 网格编码, geotile为 zoom/x/y, geohex为H3索引
+	DocCount int                        `json:"doc_count"` // 匹配文档数
+	Aggs     map[string]json.RawMessage `json:"-"`         // 子聚合, 使用DecodeAgg按名称解析
+}
+
+// UnmarshalJSON json反序列化, 同时提取子聚合
+func (b *GeoGridAggBucket) UnmarshalJSON(data []byte) error {
+	type plain GeoGridAggBucket
+	return unmarshalWithAggs(data, (*plain)(b), &b.Aggs)
+}
+
+// GeotileGridAggResult 表示 geotile_grid 聚合的结果
+type GeotileGridAggResult struct {
+	Buckets []GeoGridAggBucket `json:"buckets"`
+}
+
+// Raw 提取 geotile_grid 聚合的 JSON 数据
+func (r GeotileGridAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "geotile_grid")
+}
+
+// GeohexGridAggResult 表示 geohex_grid 聚合的结果
+type GeohexGridAggResult struct {
+	Buckets []GeoGridAggBucket `json:"buckets"`
+}
+
+// Raw 提取 geohex_grid 聚合的 JSON 数据
+func (r GeohexGridAggResult) Raw(agg map[string]json.RawMessage) []byte {
+	return rawAgg(agg, "geohex_grid")
 }
@@ -0,0 +1,88 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+// 运行时字段类型
+const (
+	RuntimeBoolean  = "boolean"
+	RuntimeDate     = "date"
+	RuntimeDouble   = "double"
+	RuntimeGeoPoint = "geo_point"
+	RuntimeIP       = "ip"
+	RuntimeKeyword  = "keyword"
+	RuntimeLong     = "long"
+)
+
+// newScript 构造painless脚本
+func newScript(source string, opts ...Option) Map {
+	s := Map{"source": source}
+	if params := NewOptMap(opts...); len(params) > 0 {
+		s["params"] = params
+	}
+	return s
+}
+
+// RuntimeField 构造运行时字段, 查询时由脚本计算字段值, 无需重建索引
+// 定义后可在Term、Range、Sort及各聚合中按普通字段名使用
+// @param name 字段名
+// @param fieldType 字段类型, 如RuntimeLong、RuntimeKeyword
+// @param source 计算字段值的脚本, 通过emit输出, 如 "emit(doc['price'].value * doc['qty'].value)"
+// @param opts option不定参数, 为script指定参数
+func RuntimeField(name, fieldType, source string, opts ...Option) Map {
+	return Map{
+		name: Map{
+			"type":   fieldType,
+			"script": newScript(source, opts...),
+		},
+	}
+}
+
+// RuntimeMappings 合并多个运行时字段, 用于ESQuery.RuntimeMappings
+func RuntimeMappings(fields ...Map) Map {
+	m := Map{}
+	for _, f := range fields {
+		maps.Copy(m, f)
+	}
+	return m
+}
+
+// ScriptField 构造脚本字段, 为每个命中文档计算并返回额外的字段值
+// @param name 返回的字段名
+// @param source 计算字段值的脚本, 如 "doc['price'].value * doc['qty'].value"
+// @param opts option不定参数, 为script指定参数
+func ScriptField(name, source string, opts ...Option) Map {
+	return Map{
+		name: Map{
+			"script": newScript(source, opts...),
+		},
+	}
+}
+
+// ScriptFields 合并多个脚本字段, 用于ESQuery.ScriptFields
+func ScriptFields(fields ...Map) Map {
+	m := Map{}
+	for _, f := range fields {
+		maps.Copy(m, f)
+	}
+	return m
+}
+
+// FieldValues 解析命中文档中脚本字段或运行时字段的值
+// @param hit 命中的文档
+// @param name 字段名
+func FieldValues[V any, T any](hit *Hit[T], name string) ([]V, error) {
+	raw, ok := hit.Fields[name]
+	if !ok {
+		return nil, nil
+	}
+
+	var values []V
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("decode field %s failed: %w", name, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,225 @@
+package esquery
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// InputPolicy 用户输入放入Lucene语法时的处理方式
+type InputPolicy int
+
+const (
+	PolicyEscape InputPolicy = iota // 转义所有语法字符, 输入按字面匹配
+	PolicyReject                    // 输入包含语法字符时返回错误
+	PolicyAllow                     // 允许语法, 但拒绝开销大的前导通配符
+)
+
+var (
+	ErrReservedSyntax  = errors.New("contains reserved syntax")
+	ErrLeadingWildcard = errors.New("starts with wildcard")
+)
+
+// InputError 用户输入不符合策略时的错误, 可用errors.Is判断ErrReservedSyntax、ErrLeadingWildcard
+type InputError struct {
+	Query string // 查询类型, 如wildcard、query_string
+	Value string // 原始输入
+	Err   error  // 具体原因
+}
+
+// Error 错误信息
+func (e *InputError) Error() string {
+	return fmt.Sprintf("%s input %q %v", e.Query, e.Value, e.Err)
+}
+
+// Unwrap 返回具体原因
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	queryStringReserved       = `+-=&|!(){}[]^"~*?:\/<>`
+	simpleQueryStringReserved = `+|-"*()~\`
+	wildcardReserved          = `*?\`
+	regexpReserved            = `.?+*|{}[]()"\#@&<>~`
+)
+
+// queryStringOperators 需转为小写以免被解析为布尔运算符的关键字
+var queryStringOperators = regexp.MustCompile(`\b(AND|OR|NOT)\b`)
+
+// leadingWildcard 以通配符开头的词项
+var leadingWildcard = regexp.MustCompile(`(^|[\s(:])[*?]`)
+
+// escapeChars 在reserved中的字符前加反斜杠
+func escapeChars(s, reserved string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if strings.ContainsRune(reserved, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// EscapeQueryString 转义query_string的保留字符(含< 和 >), 使输入按字面匹配
+// AND/OR/NOT加引号按普通词匹配, 以免被解析为运算符
+func EscapeQueryString(s string) string {
+	s = escapeChars(s, queryStringReserved)
+	return queryStringOperators.ReplaceAllString(s, `"$1"`)
+}
+
+// EscapeSimpleQueryString 转义simple_query_string的运算符, 使输入按字面匹配
+func EscapeSimpleQueryString(s string) string {
+	return escapeChars(s, simpleQueryStringReserved)
+}
+
+// EscapeWildcard 转义wildcard的通配符*和?, 使输入按字面匹配
+func EscapeWildcard(s string) string {
+	return escapeChars(s, wildcardReserved)
+}
+
+// EscapeRegexp 转义Lucene正则表达式的元字符, 使输入按字面匹配
+func EscapeRegexp(s string) string {
+	return escapeChars(s, regexpReserved)
+}
+
+// inputRule 各查询类型处理用户输入的规则
+type inputRule struct {
+	query    string              // 查询类型, 用于错误信息
+	reserved string              // 保留字符
+	escape   func(string) string // 转义函数
+	leading  func(string) bool   // 判断是否以通配符开头
+}
+
+var (
+	wildcardRule = inputRule{"wildcard", wildcardReserved, EscapeWildcard, func(s string) bool {
+		return strings.HasPrefix(s, "*") || strings.HasPrefix(s, "?")
+	}}
+	regexpRule = inputRule{"regexp", regexpReserved, EscapeRegexp, func(s string) bool {
+		return strings.HasPrefix(s, ".*") || strings.HasPrefix(s, ".+")
+	}}
+	queryStringRule       = inputRule{"query_string", queryStringReserved, EscapeQueryString, leadingWildcard.MatchString}
+	simpleQueryStringRule = inputRule{"simple_query_string", simpleQueryStringReserved, EscapeSimpleQueryString,
+		leadingWildcard.MatchString}
+)
+
+// apply 按策略处理输入
+func (r inputRule) apply(value string, policy InputPolicy) (string, error) {
+	switch policy {
+	case PolicyEscape:
+		return r.escape(value), nil
+	case PolicyReject:
+		if strings.ContainsAny(value, r.reserved) {
+			return "", &InputError{Query: r.query, Value: value, Err: ErrReservedSyntax}
+		}
+		return value, nil
+	case PolicyAllow:
+		if r.leading(value) {
+			return "", &InputError{Query: r.query, Value: value, Err: ErrLeadingWildcard}
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown input policy %d", policy)
+	}
+}
+
+// inputPolicyKey 输入策略在option参数中的键, 生成DSL前删除
+const inputPolicyKey = "input_policy"
+
+// WithInputPolicy 按策略处理用户输入(Wildcard、Regexp、QueryString、SimpleQueryString)
+// 未设置时输入原样放入查询, 不做任何处理
+// 输入不符合策略时, 查询在json序列化(如ESQuery.JSON、Simplify、发送请求)时返回*InputError, 需立即得到错误时使用Safe*构造函数
+// @param policy 处理方式
+func WithInputPolicy(policy InputPolicy) Option {
+	return func(m Map) {
+		m[inputPolicyKey] = policy
+	}
+}
+
+// withoutInputPolicy 删除输入策略, Safe*构造函数已处理输入时使用, 避免重复转义
+func withoutInputPolicy() Option {
+	return func(m Map) {
+		delete(m, inputPolicyKey)
+	}
+}
+
+// rejectedInput 不符合策略的输入, json序列化时返回错误, 使查询在发送前失败
+type rejectedInput struct {
+	err error
+}
+
+// MarshalJSON 返回输入的错误
+func (r rejectedInput) MarshalJSON() ([]byte, error) { return nil, r.err }
+
+// policyValue 取出option中的输入策略并处理输入, 未设置策略时原样返回
+// @return 处理后的输入, 不符合策略时为rejectedInput; 使用的策略
+func policyValue(paramMap Map, rule inputRule, value string) (any, InputPolicy, bool) {
+	policy, ok := paramMap[inputPolicyKey].(InputPolicy)
+	if !ok {
+		return value, 0, false
+	}
+	delete(paramMap, inputPolicyKey)
+	v, err := rule.apply(value, policy)
+	if err != nil {
+		return rejectedInput{err}, policy, true
+	}
+	return v, policy, true
+}
+
+// SafeWildcard 按策略处理用户输入后构造 Wildcard 查询
+// @param field 查询字段
+// @param value 用户输入, PolicyEscape时按字面匹配, 可再拼接通配符, 如 "*"+EscapeWildcard(s)+"*"
+// @param policy 处理方式
+// @param opts option不定参数
+func SafeWildcard(field, value string, policy InputPolicy, opts ...Option) (Map, error) {
+	v, err := wildcardRule.apply(value, policy)
+	if err != nil {
+		return nil, err
+	}
+	return Wildcard(field, v, slices.Concat(opts, []Option{withoutInputPolicy()})...), nil
+}
+
+// SafeRegexp 按策略处理用户输入后构造 Regexp 查询
+// @param field 查询字段
+// @param value 用户输入, PolicyAllow时拒绝以.*或.+开头的表达式
+// @param policy 处理方式
+// @param opts option不定参数
+func SafeRegexp(field, value string, policy InputPolicy, opts ...Option) (Map, error) {
+	v, err := regexpRule.apply(value, policy)
+	if err != nil {
+		return nil, err
+	}
+	return Regexp(field, v, slices.Concat(opts, []Option{withoutInputPolicy()})...), nil
+}
+
+// SafeQueryString 按策略处理用户输入后构造 QueryString 查询
+// PolicyAllow时同时设置allow_leading_wildcard为false, 由ES拒绝前导通配符
+// @param query 用户输入
+// @param policy 处理方式
+// @param opts option不定参数, 如WithDefaultField、WithFields
+func SafeQueryString(query string, policy InputPolicy, opts ...Option) (Map, error) {
+	v, err := queryStringRule.apply(query, policy)
+	if err != nil {
+		return nil, err
+	}
+	if policy == PolicyAllow {
+		opts = append(opts, WithAllowLeadingWildcard(false))
+	}
+	return QueryString(v, slices.Concat(opts, []Option{withoutInputPolicy()})...), nil
+}
+
+// SafeSimpleQueryString 按策略处理用户输入后构造 SimpleQueryString 查询
+// @param query 用户输入
+// @param policy 处理方式
+// @param opts option不定参数, 如WithFields、WithFlags
+func SafeSimpleQueryString(query string, policy InputPolicy, opts ...Option) (Map, error) {
+	v, err := simpleQueryStringRule.apply(query, policy)
+	if err != nil {
+		return nil, err
+	}
+	return SimpleQueryString(v, slices.Concat(opts, []Option{withoutInputPolicy()})...), nil
+}
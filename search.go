@@ -22,7 +22,7 @@ type RawAgg interface {
 	Raw(map[string]json.RawMessage) []byte
 }
 
-// QueryAgg 查询聚合并将结果解析到指定结构体中
+// QueryAgg 查询聚合并将结果解析到指定结构体中, 按默认名称 field_type 查找T对应类型的聚合, 按名称查找使用QueryAggNamed
 func QueryAgg[T RawAgg](es *elasticsearch.Client, index string, queryBody any) (*T, error) {
 	_, _, aggsRaw, _, err := QueryWithMeta[any](es, index, queryBody)
 	if err != nil {
@@ -31,13 +31,42 @@ func QueryAgg[T RawAgg](es *elasticsearch.Client, index string, queryBody any) (
 
 	// 用泛型解析聚合部分
 	var result T
-	if err := json.Unmarshal(result.Raw(aggsRaw), &result); err != nil {
+	raw := result.Raw(aggsRaw)
+	if raw == nil {
+		return nil, fmt.Errorf("aggregation of %T not found", result)
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
 
+// QueryAggNamed 查询聚合并将指定名称的聚合结果解析到结构体中
+// @param name 聚合名称, 默认为 field_type, 或Named指定的名称
+func QueryAggNamed[T any](es *elasticsearch.Client, index string, queryBody any, name string) (*T, error) {
+	_, _, aggsRaw, _, err := QueryWithMeta[any](es, index, queryBody)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeAgg[T](aggsRaw, name)
+}
+
+// DecodeAgg 将指定名称的聚合结果解析到结构体中, 也可用于解析桶内的子聚合
+// @param aggs 聚合结果, 如QueryAggRaw的返回
+// @param name 聚合名称
+func DecodeAgg[T any](aggs map[string]json.RawMessage, name string) (*T, error) {
+	raw, ok := aggs[name]
+	if !ok {
+		return nil, fmt.Errorf("aggregation %s not found", name)
+	}
+	var result T
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("decode aggregation %s failed: %w", name, err)
+	}
+	return &result, nil
+}
+
 // QueryAggRaw 聚合分析查询，返回原始json序列
 func QueryAggRaw(es *elasticsearch.Client, index string, queryBody any,
 ) (map[string]json.RawMessage, error) {
@@ -48,9 +77,48 @@ func QueryAggRaw(es *elasticsearch.Client, index string, queryBody any,
 // QueryWithMeta 检索及聚合分析结果
 func QueryWithMeta[T any](es *elasticsearch.Client, index string, queryBody any,
 ) ([]*T, int, map[string]json.RawMessage, []string, error) {
+	parsed, err := search[T](es, index, queryBody)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+
+	var results []*T
+	var ids []string
+	for _, hit := range parsed.Hits.Hits {
+		results = append(results, hit.Source)
+		ids = append(ids, hit.ID)
+	}
+
+	return results, parsed.Hits.Total.Value, parsed.Aggregations, ids, nil
+}
+
+// QueryHits 查询命中的文档，保留ID、评分及排序值等元信息
+func QueryHits[T any](es *elasticsearch.Client, index string, queryBody any,
+) ([]*Hit[T], int, error) {
+	parsed, err := search[T](es, index, queryBody)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]*Hit[T], 0, len(parsed.Hits.Hits))
+	for i := range parsed.Hits.Hits {
+		hits = append(hits, &parsed.Hits.Hits[i])
+	}
+	return hits, parsed.Hits.Total.Value, nil
+}
+
+// search 执行搜索请求并解析响应
+func search[T any](es *elasticsearch.Client, index string, queryBody any) (*Result[T], error) {
+	// 发送前校验聚合, 如管道聚合的buckets_path
+	if aggs := queryAggs(queryBody); len(aggs) > 0 {
+		if err := (AggsMap{Aggs: aggs}).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid aggregations: %w", err)
+		}
+	}
+
 	queryBytes, err := json.Marshal(queryBody)
 	if err != nil {
-		return nil, 0, nil, nil, fmt.Errorf("marshal query failed: %w", err)
+		return nil, fmt.Errorf("marshal query failed: %w", err)
 	}
 
 	// 执行搜索请求
@@ -61,27 +129,38 @@ func QueryWithMeta[T any](es *elasticsearch.Client, index string, queryBody any,
 		es.Search.WithTrackTotalHits(true),
 	)
 	if err != nil {
-		return nil, 0, nil, nil, fmt.Errorf("es search failed: %w", err)
+		return nil, fmt.Errorf("es search failed: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
 		body, _ := io.ReadAll(res.Body)
-		return nil, 0, nil, nil, fmt.Errorf("es search error: %s", body)
+		return nil, fmt.Errorf("es search error: %s", body)
 	}
 
 	// 解析响应
 	var parsed Result[T]
 	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
-		return nil, 0, nil, nil, fmt.Errorf("decode response failed: %w", err)
+		return nil, fmt.Errorf("decode response failed: %w", err)
 	}
+	return &parsed, nil
+}
 
-	var results []*T
-	var ids []string
-	for _, hit := range parsed.Hits.Hits {
-		results = append(results, hit.Source)
-		ids = append(ids, hit.ID)
+// queryAggs 取出请求体中的聚合, 请求体可为ESQuery、*ESQuery或Map
+func queryAggs(queryBody any) Map {
+	switch q := queryBody.(type) {
+	case *ESQuery:
+		if q != nil {
+			return q.Aggs
+		}
+	case ESQuery:
+		return q.Aggs
+	case Map:
+		for _, key := range []string{"aggs", "aggregations"} {
+			if aggs, ok := q[key].(Map); ok {
+				return aggs
+			}
+		}
 	}
-
-	return results, parsed.Hits.Total.Value, parsed.Aggregations, ids, nil
+	return nil
 }
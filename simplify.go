@@ -0,0 +1,329 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// boolClauses Bool查询的子句
+var boolClauses = []string{"must", "filter", "should", "must_not"}
+
+// nonScoringQueries 改为filter后不影响业务的结构化查询类型, 仅WithMustToFilter时使用
+var nonScoringQueries = []string{
+	"term", "terms", "terms_set", "range", "exists", "ids", "prefix", "wildcard", "regexp",
+	"geo_distance", "geo_bounding_box", "geo_polygon", "geo_shape", "script",
+}
+
+// WithMustToFilter 将must中的结构化查询(Term、Range、Exists等)移到filter, 不再参与打分(Simplify)
+func WithMustToFilter() Option {
+	return func(m Map) {
+		m["must_to_filter"] = true
+	}
+}
+
+// simplifier Bool查询的化简参数
+type simplifier struct {
+	mustToFilter bool
+}
+
+// Simplify 化简Bool查询, 生成规范的DSL, 便于阅读和缓存
+// 删除空子句, 单子句的Bool替换为子句本身, 合并嵌套的Bool, 同字段的Term合并为Terms, 删除重复子句并排序
+// 重复的must子句会被删除, 得分可能与化简前不同
+// @param query 查询语句, 子句可为Map、[]Map、[]any或Querier
+// @param opts option不定参数, 如WithMustToFilter
+func Simplify(query Map, opts ...Option) (Map, error) {
+	m := NewOptMap(opts...)
+	s := simplifier{}
+	s.mustToFilter, _ = m["must_to_filter"].(bool)
+	return s.simplify(query, true)
+}
+
+// simplify 化简查询, scoring表示当前是否处于打分上下文
+func (s simplifier) simplify(query Map, scoring bool) (Map, error) {
+	params, ok := boolParams(query)
+	if !ok {
+		return query, nil
+	}
+
+	out := Map{}
+	for k, v := range params {
+		if !slices.Contains(boolClauses, k) {
+			out[k] = v
+		}
+	}
+	_, hasMsm := out["minimum_should_match"]
+
+	clauses := map[string][]Map{}
+	required := false
+	for _, key := range boolClauses {
+		children, err := toClauses(params[key])
+		if err != nil {
+			return nil, fmt.Errorf("bool %s: %w", key, err)
+		}
+		if len(children) > 0 && (key == "must" || key == "filter") {
+			required = true
+		}
+		childScoring := scoring && (key == "must" || key == "should")
+		for _, child := range children {
+			child, err = s.simplify(child, childScoring)
+			if err != nil {
+				return nil, err
+			}
+			s.place(clauses, key, child, scoring, hasMsm)
+		}
+	}
+
+	clauses["must_not"] = mergeTerms(clauses["must_not"])
+	if !scoring && !hasMsm {
+		clauses["should"] = mergeTerms(clauses["should"])
+	}
+	total := 0
+	for _, key := range boolClauses {
+		list, err := canonical(clauses[key], key != "should" || !hasMsm)
+		if err != nil {
+			return nil, fmt.Errorf("bool %s: %w", key, err)
+		}
+		clauses[key] = list
+		if len(clauses[key]) > 0 {
+			out[key] = clauses[key]
+			total += len(clauses[key])
+		}
+	}
+
+	// 必须子句被删除后, should由可选变为必须满足, 需保持原语义
+	if required && len(clauses["must"]) == 0 && len(clauses["filter"]) == 0 && len(clauses["should"]) > 0 && !hasMsm {
+		out["minimum_should_match"] = 0
+	}
+
+	if total == 1 && len(out) == 1 {
+		switch {
+		case len(clauses["must"]) == 1:
+			return clauses["must"][0], nil
+		case len(clauses["should"]) == 1:
+			return clauses["should"][0], nil
+		case len(clauses["filter"]) == 1 && !scoring:
+			return clauses["filter"][0], nil
+		}
+	}
+	return Map{"bool": out}, nil
+}
+
+// place 将化简后的子句放入对应位置, 可合并的Bool展开到父级
+func (s simplifier) place(clauses map[string][]Map, key string, child Map, scoring, hasMsm bool) {
+	if key == "must" && (!scoring || s.mustToFilter && isQueryType(child, nonScoringQueries)) {
+		key = "filter"
+	}
+
+	params, ok := boolParams(child)
+	if !ok {
+		clauses[key] = append(clauses[key], child)
+		return
+	}
+
+	plain := true
+	empty := true
+	for k, v := range params {
+		if !slices.Contains(boolClauses, k) {
+			plain = false
+		} else if n, _ := toClauses(v); len(n) > 0 {
+			empty = false
+		}
+	}
+	if empty && plain && (key == "must" || key == "filter") {
+		return
+	}
+	if !plain {
+		clauses[key] = append(clauses[key], child)
+		return
+	}
+
+	sub := func(k string) []Map {
+		list, _ := params[k].([]Map)
+		return list
+	}
+	switch {
+	case (key == "must" || key == "filter") && len(sub("should")) == 0:
+		for _, c := range sub("must") {
+			s.place(clauses, key, c, scoring, hasMsm)
+		}
+		for _, c := range sub("filter") {
+			s.place(clauses, "filter", c, scoring, hasMsm)
+		}
+		for _, c := range sub("must_not") {
+			s.place(clauses, "must_not", c, scoring, hasMsm)
+		}
+	case key == "should" && !hasMsm && len(params) == 1 && len(sub("should")) > 0:
+		for _, c := range sub("should") {
+			s.place(clauses, "should", c, scoring, hasMsm)
+		}
+	default:
+		clauses[key] = append(clauses[key], child)
+	}
+}
+
+// boolParams 返回Bool查询的参数
+func boolParams(query Map) (Map, bool) {
+	if len(query) != 1 {
+		return nil, false
+	}
+	params, ok := query["bool"].(Map)
+	return params, ok
+}
+
+// isQueryType 判断查询是否为指定类型之一
+func isQueryType(query Map, types []string) bool {
+	if len(query) != 1 {
+		return false
+	}
+	for k := range query {
+		return slices.Contains(types, k)
+	}
+	return false
+}
+
+// toClauses 将子句统一转为[]Map
+func toClauses(v any) ([]Map, error) {
+	switch c := v.(type) {
+	case nil:
+		return nil, nil
+	case Map:
+		return []Map{c}, nil
+	case []Map:
+		return c, nil
+	case []any:
+		list := make([]Map, 0, len(c))
+		for _, item := range c {
+			sub, err := toClauses(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, sub...)
+		}
+		return list, nil
+	case Querier:
+		m, err := ToMap(c)
+		if err != nil {
+			return nil, err
+		}
+		return []Map{m}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 && data[0] == '[' {
+		var list []Map
+		err = json.Unmarshal(data, &list)
+		return list, err
+	}
+	var m Map
+	err = json.Unmarshal(data, &m)
+	return []Map{m}, err
+}
+
+// canonical 按json排序子句, dedupe时删除重复子句, 子句无法序列化时返回错误
+func canonical(list []Map, dedupe bool) ([]Map, error) {
+	type keyed struct {
+		key string
+		m   Map
+	}
+	items := make([]keyed, 0, len(list))
+	seen := map[string]bool{}
+	for _, m := range list {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		key := string(data)
+		if dedupe && seen[key] {
+			continue
+		}
+		seen[key] = true
+		items = append(items, keyed{key, m})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].key < items[j].key })
+
+	out := make([]Map, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.m)
+	}
+	return out, nil
+}
+
+// mergeTerms 将同一字段的Term、Terms合并为一个Terms, 仅用于或关系的子句(should、must_not)
+func mergeTerms(list []Map) []Map {
+	values := map[string][]any{}
+	counts := map[string]int{}
+	for _, m := range list {
+		if field, vals, ok := termValues(m); ok {
+			values[field] = append(values[field], vals...)
+			counts[field]++
+		}
+	}
+
+	out := make([]Map, 0, len(list))
+	for _, m := range list {
+		field, _, ok := termValues(m)
+		if !ok || counts[field] < 2 {
+			out = append(out, m)
+			continue
+		}
+		if vals, ok := values[field]; ok {
+			out = append(out, Terms(field, uniqueValues(vals)))
+			delete(values, field)
+		}
+	}
+	return out
+}
+
+// termValues 解析不带其它参数的Term、Terms查询的字段和值
+func termValues(m Map) (string, []any, bool) {
+	if len(m) != 1 {
+		return "", nil, false
+	}
+	if body, ok := m["term"].(Map); ok && len(body) == 1 {
+		for field, v := range body {
+			if opts, ok := v.(Map); ok {
+				value, ok := opts["value"]
+				if !ok || len(opts) != 1 {
+					return "", nil, false
+				}
+				v = value
+			}
+			return field, []any{v}, true
+		}
+	}
+	if body, ok := m["terms"].(Map); ok && len(body) == 1 {
+		for field, v := range body {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice {
+				return "", nil, false
+			}
+			vals := make([]any, rv.Len())
+			for i := range vals {
+				vals[i] = rv.Index(i).Interface()
+			}
+			return field, vals, true
+		}
+	}
+	return "", nil, false
+}
+
+// uniqueValues 删除重复的值, 保持原顺序
+func uniqueValues(vals []any) []any {
+	seen := map[string]bool{}
+	out := make([]any, 0, len(vals))
+	for _, v := range vals {
+		data, _ := json.Marshal(v)
+		if seen[string(data)] {
+			continue
+		}
+		seen[string(data)] = true
+		out = append(out, v)
+	}
+	return out
+}
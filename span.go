@@ -0,0 +1,143 @@
+package esquery
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Span 跨度查询, 只能与其它跨度查询组合, 可作为Querier放入Bool子句
+type Span struct {
+	m   Map   // 查询DSL
+	err error // 构造时的参数错误
+}
+
+// Map 查询的DSL, 用于放入[]Map形式的Bool子句, 参数不合法时返回错误
+func (s Span) Map() (Map, error) {
+	return ToMap(s)
+}
+
+// Source 生成查询DSL, 参数不合法时返回错误
+func (s Span) Source() (any, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.m, nil
+}
+
+// MarshalJSON json序列化
+func (s Span) MarshalJSON() ([]byte, error) { return marshalQuery(s) }
+
+// spanOf 构造跨度查询, 并收集子句中的错误
+func spanOf(qtype string, params Map, clauses ...Span) Span {
+	for _, c := range clauses {
+		if c.err != nil {
+			return Span{err: c.err}
+		}
+		if c.m == nil {
+			return Span{err: fmt.Errorf("%s has empty span clause", qtype)}
+		}
+	}
+	return Span{m: Map{qtype: params}}
+}
+
+// SpanTerm 构造 SpanTerm 查询, 匹配单个词项
+// @param field 查询字段
+// @param value 词项, 不进行分词
+// @param opts option不定参数, 如WithBoost
+func SpanTerm(field string, value any, opts ...Option) Span {
+	paramMap := NewOptMap(opts...)
+	paramMap["value"] = value
+	return spanOf("span_term", Map{field: paramMap})
+}
+
+// SpanNear 构造 SpanNear 查询, 子句在slop个位置内出现, 如"A与B相距5个词以内且按顺序"
+// @param clauses 子句列表
+// @param slop 子句之间允许的最大间隔位置数
+// @param inOrder 是否需按顺序出现
+// @param opts option不定参数
+func SpanNear(clauses []Span, slop int, inOrder bool, opts ...Option) Span {
+	paramMap := NewOptMap(opts...)
+	paramMap["clauses"] = clauses
+	paramMap["slop"] = slop
+	paramMap["in_order"] = inOrder
+	return spanOf("span_near", paramMap, clauses...)
+}
+
+// SpanOr 构造 SpanOr 查询, 匹配任一子句
+// @param clauses 子句列表
+func SpanOr(clauses ...Span) Span {
+	return spanOf("span_or", Map{"clauses": clauses}, clauses...)
+}
+
+// SpanNot 构造 SpanNot 查询, 排除与exclude重叠的include匹配, 如"X但不靠近Y"
+// @param include 需匹配的跨度
+// @param exclude 需排除的跨度
+// @param opts option不定参数, 如WithPre、WithPost、WithDist
+func SpanNot(include, exclude Span, opts ...Option) Span {
+	paramMap := NewOptMap(opts...)
+	paramMap["include"] = include
+	paramMap["exclude"] = exclude
+	return spanOf("span_not", paramMap, include, exclude)
+}
+
+// SpanFirst 构造 SpanFirst 查询, 匹配需出现在字段的前end个位置内
+// @param match 需匹配的跨度
+// @param end 结束位置
+func SpanFirst(match Span, end int) Span {
+	return spanOf("span_first", Map{"match": match, "end": end}, match)
+}
+
+// SpanContaining 构造 SpanContaining 查询, 返回包含little的big匹配
+// @param big 外层跨度
+// @param little 内层跨度
+func SpanContaining(big, little Span) Span {
+	return spanOf("span_containing", Map{"big": big, "little": little}, big, little)
+}
+
+// SpanWithin 构造 SpanWithin 查询, 返回被big包含的little匹配
+// @param big 外层跨度
+// @param little 内层跨度
+func SpanWithin(big, little Span) Span {
+	return spanOf("span_within", Map{"big": big, "little": little}, big, little)
+}
+
+// spanMultiTypes 可被span_multi包装的查询类型
+var spanMultiTypes = []string{"prefix", "wildcard", "fuzzy", "regexp", "range"}
+
+// SpanMulti 构造 SpanMulti 查询, 将Prefix、Wildcard、Fuzzy、Regexp、Range查询包装为跨度查询
+// @param query 被包装的查询
+func SpanMulti(query Map) Span {
+	if len(query) != 1 {
+		return Span{err: fmt.Errorf("span_multi requires exactly one query")}
+	}
+	for qtype := range query {
+		if !slices.Contains(spanMultiTypes, qtype) {
+			return Span{err: fmt.Errorf("span_multi does not support %s query", qtype)}
+		}
+	}
+	return spanOf("span_multi", Map{"match": query})
+}
+
+// WithPre include匹配之前不能有exclude匹配的位置数(span_not)
+// @param value 位置数
+func WithPre(value int) Option {
+	return func(m Map) {
+		m["pre"] = value
+	}
+}
+
+// WithPost include匹配之后不能有exclude匹配的位置数(span_not)
+// @param value 位置数
+func WithPost(value int) Option {
+	return func(m Map) {
+		m["post"] = value
+	}
+}
+
+// WithDist include匹配前后不能有exclude匹配的位置数, 相当于同时设置pre和post(span_not)
+// @param value 位置数
+func WithDist(value int) Option {
+	return func(m Map) {
+		m["dist"] = value
+	}
+}
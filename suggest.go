@@ -0,0 +1,250 @@
+package esquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// SuggestMap 搜索建议参数的Map
+type SuggestMap struct {
+	Suggest Map // 建议器名称到参数的映射
+}
+
+// With 组合多个建议器, 返回新的SuggestMap, 不修改原对象
+func (s SuggestMap) With(m SuggestMap) SuggestMap {
+	merged := make(Map, len(s.Suggest)+len(m.Suggest))
+	maps.Copy(merged, s.Suggest)
+	maps.Copy(merged, m.Suggest)
+	return SuggestMap{Suggest: merged}
+}
+
+// Suggester 构造建议器
+// @param name 建议器名称, 结果按该名称返回
+// @param textKey 输入文本的参数名, text或prefix
+// @param text 输入文本
+// @param suggestType 建议器类型term/phrase/completion
+// @param field 建议字段
+// @param opts option不定参数
+func Suggester(name, textKey, text, suggestType, field string, opts ...Option) SuggestMap {
+	paramMap := NewOptMap(opts...)
+	paramMap["field"] = field
+	return SuggestMap{Suggest: Map{
+		name: Map{
+			textKey:     text,
+			suggestType: paramMap,
+		},
+	}}
+}
+
+// TermSuggester 构造Term建议器, 对输入的每个词给出拼写纠正
+// @param name 建议器名称
+// @param text 输入文本
+// @param field 建议字段
+// @param opts option不定参数
+func TermSuggester(name, text, field string, opts ...Option) SuggestMap {
+	return Suggester(name, "text", text, "term", field, opts...)
+}
+
+// PhraseSuggester 构造Phrase建议器, 对整个短语给出纠正("您是不是要找")
+// @param name 建议器名称
+// @param text 输入文本
+// @param field 建议字段, 一般为shingle分词的字段
+// @param opts option不定参数
+func PhraseSuggester(name, text, field string, opts ...Option) SuggestMap {
+	return Suggester(name, "text", text, "phrase", field, opts...)
+}
+
+// CompletionSuggester 构造Completion建议器, 基于completion类型字段的前缀自动补全
+// @param name 建议器名称
+// @param prefix 输入的前缀
+// @param field completion类型的字段
+// @param opts option不定参数
+func CompletionSuggester(name, prefix, field string, opts ...Option) SuggestMap {
+	return Suggester(name, "prefix", prefix, "completion", field, opts...)
+}
+
+// suggest_mode的枚举值
+var (
+	SuggestMissing = "missing" // 仅对索引中不存在的词给出建议(默认)
+	SuggestPopular = "popular" // 仅建议比原词出现更频繁的词
+	SuggestAlways  = "always"  // 总是给出建议
+)
+
+// WithSuggestMode 控制何时给出建议(term/phrase)
+// @param value missing/popular/always
+func WithSuggestMode(value string) Option {
+	return func(m Map) {
+		m["suggest_mode"] = value
+	}
+}
+
+// WithMaxEdits 建议词与原词的最大编辑距离(term)
+// @param value 1或2, 默认2
+func WithMaxEdits(value int) Option {
+	return func(m Map) {
+		m["max_edits"] = value
+	}
+}
+
+// WithPrefixLength 不参与模糊匹配的前缀长度
+// @param value 前缀字符数
+func WithPrefixLength(value int) Option {
+	return func(m Map) {
+		m["prefix_length"] = value
+	}
+}
+
+// WithMinWordLength 参与建议的最小词长度(term)
+// @param value 字符数, 默认4
+func WithMinWordLength(value int) Option {
+	return func(m Map) {
+		m["min_word_length"] = value
+	}
+}
+
+// WithConfidence 建议短语的置信度阈值(phrase)
+// @param value 阈值, 默认1.0
+func WithConfidence(value float64) Option {
+	return func(m Map) {
+		m["confidence"] = value
+	}
+}
+
+// WithMaxErrors 允许纠正的最大错误词数或比例(phrase)
+// @param value 个数或小于1的比例, 默认1
+func WithMaxErrors(value float64) Option {
+	return func(m Map) {
+		m["max_errors"] = value
+	}
+}
+
+// WithGramSize 字段shingle的最大长度(phrase)
+// @param value shingle长度
+func WithGramSize(value int) Option {
+	return func(m Map) {
+		m["gram_size"] = value
+	}
+}
+
+// WithDirectGenerator 候选词生成器(phrase)
+// @param generators 生成器列表, 如 {"field": "title.trigram", "suggest_mode": "always"}
+func WithDirectGenerator(generators ...Map) Option {
+	return func(m Map) {
+		m["direct_generator"] = generators
+	}
+}
+
+// WithHighlightTags 高亮被纠正的词(phrase)
+// @param pre 前置标签
+// @param post 后置标签
+func WithHighlightTags(pre, post string) Option {
+	return func(m Map) {
+		m["highlight"] = Map{"pre_tag": pre, "post_tag": post}
+	}
+}
+
+// WithCollate 用查询校验每个建议短语, 剔除无结果的建议(phrase)
+// @param query 校验查询模板, 建议短语通过{{suggestion}}引用
+// @param prune 是否保留未命中的建议并以collate_match标记
+// @param params 模板参数
+func WithCollate(query Map, prune bool, params Map) Option {
+	return func(m Map) {
+		collate := Map{"query": Map{"source": query}, "prune": prune}
+		if len(params) > 0 {
+			collate["params"] = params
+		}
+		m["collate"] = collate
+	}
+}
+
+// WithContexts 按上下文过滤或加权补全结果(completion)
+// @param contexts 上下文名称到值的映射, 如 {"place_type": ["cafe"]}
+func WithContexts(contexts Map) Option {
+	return func(m Map) {
+		m["contexts"] = contexts
+	}
+}
+
+// WithFuzzy 补全时允许前缀存在拼写错误(completion)
+// @param opts WithFuzziness、WithPrefixLength、WithTranspositions等
+func WithFuzzy(opts ...Option) Option {
+	return func(m Map) {
+		m["fuzzy"] = NewOptMap(opts...)
+	}
+}
+
+// WithTranspositions 相邻字符交换是否算作一次编辑
+// @param value 默认true
+func WithTranspositions(value bool) Option {
+	return func(m Map) {
+		m["transpositions"] = value
+	}
+}
+
+// WithSkipDuplicates 过滤重复的补全结果(completion)
+// @param value 是否过滤, 默认false
+func WithSkipDuplicates(value bool) Option {
+	return func(m Map) {
+		m["skip_duplicates"] = value
+	}
+}
+
+// SuggestOption 单条建议
+type SuggestOption[T any] struct {
+	Text         string              `json:"text"`                    // 建议文本
+	Score        float64             `json:"score"`                   // 建议评分
+	Freq         int                 `json:"freq,omitempty"`          // 建议词的文档频率(term)
+	Highlighted  string              `json:"highlighted,omitempty"`   // 高亮后的文本(phrase)
+	CollateMatch *bool               `json:"collate_match,omitempty"` // 校验查询是否命中(phrase)
+	Index        string              `json:"_index,omitempty"`        // 所属索引(completion)
+	ID           string              `json:"_id,omitempty"`           // 文档ID(completion)
+	Source       *T                  `json:"_source,omitempty"`       // 文档内容(completion)
+	Contexts     map[string][]string `json:"contexts,omitempty"`      // 命中的上下文(completion)
+}
+
+// SuggestEntry 输入文本中一个词(或整个短语)的建议列表
+type SuggestEntry[T any] struct {
+	Text    string             `json:"text"`    // 原始文本
+	Offset  int                `json:"offset"`  // 在输入中的偏移
+	Length  int                `json:"length"`  // 文本长度
+	Options []SuggestOption[T] `json:"options"` // 建议列表
+}
+
+// SuggestResult 建议器名称到建议结果的映射
+type SuggestResult[T any] map[string][]SuggestEntry[T]
+
+// ParseSuggest 解析查询结果中的suggest部分
+func ParseSuggest[T any](suggest map[string]json.RawMessage) (SuggestResult[T], error) {
+	result := SuggestResult[T]{}
+	for name, raw := range suggest {
+		var entries []SuggestEntry[T]
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("decode suggest %s failed: %w", name, err)
+		}
+		result[name] = entries
+	}
+	return result, nil
+}
+
+// QuerySuggest 执行带suggest的查询, 返回建议结果
+func QuerySuggest[T any](es *elasticsearch.Client, index string, queryBody any) (SuggestResult[T], error) {
+	parsed, err := search[T](es, index, queryBody)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSuggest[T](parsed.Suggest)
+}
+
+// Suggest 仅执行建议器, 不返回检索结果
+// @param suggesters 建议器列表
+func Suggest[T any](es *elasticsearch.Client, index string, suggesters ...SuggestMap) (SuggestResult[T], error) {
+	var sm SuggestMap
+	for _, s := range suggesters {
+		sm = sm.With(s)
+	}
+	body := Map{"size": 0, "suggest": sm.Suggest}
+	return QuerySuggest[T](es, index, body)
+}
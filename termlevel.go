@@ -0,0 +1,128 @@
+package esquery
+
+// valueQuery 构造 {qtype: {field: {"value": value, 参数...}}} 形式的查询
+func valueQuery(qtype, field string, value any, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["value"] = value
+	return Map{
+		qtype: Map{
+			field: paramMap,
+		},
+	}
+}
+
+// Prefix 构造 Prefix 查询, 匹配以指定前缀开头的词项
+// @param field 查询字段
+// @param value 前缀
+// @param opts option不定参数, 如WithCaseInsensitive
+func Prefix(field string, value string, opts ...Option) Map {
+	return valueQuery("prefix", field, value, opts...)
+}
+
+// Regexp 构造 Regexp 查询, 匹配符合正则表达式的词项
+// @param field 查询字段
+// @param value Lucene正则表达式
+// @param opts option不定参数, 如WithFlags、WithMaxDeterminizedStates、WithCaseInsensitive、WithInputPolicy
+func Regexp(field string, value string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["value"], _, _ = policyValue(paramMap, regexpRule, value)
+	return Map{
+		"regexp": Map{
+			field: paramMap,
+		},
+	}
+}
+
+// Fuzzy 构造 Fuzzy 查询, 匹配与查询词编辑距离在范围内的词项
+// @param field 查询字段
+// @param value 查询词
+// @param opts option不定参数, 如WithFuzziness、WithPrefixLength、WithTranspositions、WithMaxExpansions
+func Fuzzy(field string, value string, opts ...Option) Map {
+	return valueQuery("fuzzy", field, value, opts...)
+}
+
+// IDs 构造 IDs 查询, 按文档ID匹配
+// @param ids 文档ID列表
+func IDs(ids ...string) Map {
+	return Map{
+		"ids": Map{
+			"values": ids,
+		},
+	}
+}
+
+// TermsSet 构造 TermsSet 查询, 文档需至少匹配指定个数的词项, 个数由字段或脚本决定
+// @param field 查询字段
+// @param values 查询值列表
+// @param opts option不定参数, WithMinimumShouldMatchField或WithMinimumShouldMatchScript
+func TermsSet[V any](field string, values []V, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["terms"] = values
+	return Map{
+		"terms_set": Map{
+			field: paramMap,
+		},
+	}
+}
+
+// TermsLookup 构造 Terms 查询, 查询值从另一个索引的文档字段中读取
+// @param field 查询字段
+// @param index 存放查询值的索引
+// @param id 存放查询值的文档ID
+// @param path 文档中存放查询值的字段
+// @param opts option不定参数, 如WithRouting
+func TermsLookup(field, index, id, path string, opts ...Option) Map {
+	paramMap := NewOptMap(opts...)
+	paramMap["index"] = index
+	paramMap["id"] = id
+	paramMap["path"] = path
+	return Map{
+		"terms": Map{
+			field: paramMap,
+		},
+	}
+}
+
+// regexp的flags枚举值, 多个值用|连接
+var (
+	RegexpAll          = "ALL"          // 启用全部操作符(默认)
+	RegexpNone         = "NONE"         // 禁用全部操作符
+	RegexpComplement   = "COMPLEMENT"   // ~ 取反
+	RegexpEmpty        = "EMPTY"        // # 空语言
+	RegexpInterval     = "INTERVAL"     // <> 数值区间
+	RegexpIntersection = "INTERSECTION" // & 交集
+	RegexpAnyString    = "ANYSTRING"    // @ 任意字符串
+)
+
+// WithMaxDeterminizedStates 正则表达式编译后允许的最大自动机状态数(regexp)
+// @param value 状态数, 默认10000
+func WithMaxDeterminizedStates(value int) Option {
+	return func(m Map) {
+		m["max_determinized_states"] = value
+	}
+}
+
+// WithMinimumShouldMatchField 由文档中的数值字段决定需匹配的词项个数(terms_set)
+// @param field 数值字段
+func WithMinimumShouldMatchField(field string) Option {
+	return func(m Map) {
+		m["minimum_should_match_field"] = field
+	}
+}
+
+// WithMinimumShouldMatchScript 由脚本计算需匹配的词项个数(terms_set)
+// @param source 脚本, 可通过params.num_terms获取查询词个数
+// @param opts option不定参数, 为script指定参数
+func WithMinimumShouldMatchScript(source string, opts ...Option) Option {
+	return func(m Map) {
+		m["minimum_should_match_script"] = newScript(source, opts...)
+	}
+}
+
+// WithRouting 指定路由值
+// @param value 路由值
+func WithRouting(value string) Option {
+	return func(m Map) {
+		m["routing"] = value
+	}
+}
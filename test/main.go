@@ -49,13 +49,16 @@ func TestEsQuery() {
 	}
 
 	// 聚合：按类别统计总数
-	aggs := eq.TermsAgg("category", eq.WithSize(1))
+	aggs, err := eq.TermsAgg("category", eq.WithSize(1)).Build()
+	if err != nil {
+		log.Fatalf("Error building aggregations: %s", err)
+	}
 
 	// 构造 Elasticsearch 查询
 	esQuery := eq.ESQuery{
 		Query: eq.Bool(eq.WithMust(mustQueries), eq.WithShould(shouldQueries), eq.WithFilter(filterQueries), eq.WithMustNot(mustNotQueries)),
 		Sort:  sort,
-		Aggs:  aggs.Aggs,
+		Aggs:  aggs,
 	}
 
 	// 输出查询 JSON
@@ -189,13 +192,22 @@ func main() {
 		log.Fatalf("Error creating the client: %s", err)
 	}
 
+	aggs, err := eq.TermsAgg("name.keyword", eq.WithSize(8)).Build()
+	if err != nil {
+		log.Fatalf("Error building aggregations: %s", err)
+	}
+
 	// // 构造 Elasticsearch 查询
 	esQuery := eq.ESQuery{
 		// Query: eq.Bool(eq.WithMust([]eq.Map{eq.Match("name", "snow")})),
 		Query: eq.Match("name", "snow"),
-		Aggs:  eq.TermsAgg("name.keyword", eq.WithSize(8)).Aggs,
+		Aggs:  aggs,
+	}
+	dsl, err := esQuery.JSON()
+	if err != nil {
+		log.Fatalf("Error encoding the query: %s", err)
 	}
-	fmt.Println(esQuery.JSON())
+	fmt.Println(dsl)
 
 	l, t, err := eq.QueryList[Books](es, "books", esQuery)
 	lj, _ := json.Marshal(l)